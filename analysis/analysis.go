@@ -0,0 +1,296 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/gookit/ini/v2"
+	"github.com/notnil/chess"
+
+	"github.com/loloof64/chess-pgn-reviser-fyne/chessboard"
+	"github.com/loloof64/chess-pgn-reviser-fyne/engine"
+)
+
+// Panel shows the analysis of a UCI engine for the displayed position.
+type Panel struct {
+	widget.BaseWidget
+
+	parent fyne.Window
+
+	// mutex guards the engine, which is started in the background.
+	mutex               sync.Mutex
+	engine              *engine.Engine
+	enginePath          string
+	enabled             bool
+	analyzedFen         string
+	onEnginePathChanged func(path string)
+	onInfo              func(info engine.Info, sideToMove chess.Color)
+	onCleared           func()
+	onEvaluationBar     func(shown bool)
+
+	engineLabel        *widget.Label
+	evaluationLabel    *widget.Label
+	lineLabel          *widget.Label
+	evaluationBarCheck *widget.Check
+	content            fyne.CanvasObject
+}
+
+// NewPanel creates an analysis panel, without engine until SetEnginePath is called.
+func NewPanel(parent fyne.Window) *Panel {
+	panel := &Panel{parent: parent, enabled: true}
+	panel.ExtendBaseWidget(panel)
+
+	panel.engineLabel = widget.NewLabel(ini.String("analysis.noEngine"))
+	panel.engineLabel.Wrapping = fyne.TextTruncate
+	panel.evaluationLabel = widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	panel.lineLabel = widget.NewLabel("")
+	panel.lineLabel.Wrapping = fyne.TextWrapWord
+
+	chooseEngineButton := widget.NewButtonWithIcon("", theme.ComputerIcon(), panel.chooseEngine)
+	enabledCheck := widget.NewCheck(ini.String("analysis.enabled"), panel.setEnabled)
+	enabledCheck.SetChecked(true)
+	panel.evaluationBarCheck = widget.NewCheck(ini.String("analysis.evaluationBar"), func(shown bool) {
+		if panel.onEvaluationBar != nil {
+			panel.onEvaluationBar(shown)
+		}
+	})
+	checks := container.NewHBox(enabledCheck, panel.evaluationBarCheck)
+
+	header := fyne.NewContainerWithLayout(
+		layout.NewBorderLayout(nil, nil, chooseEngineButton, checks),
+		chooseEngineButton,
+		checks,
+		panel.engineLabel,
+	)
+	panel.content = container.NewVBox(header, panel.evaluationLabel, panel.lineLabel)
+
+	return panel
+}
+
+// CreateRenderer creates the renderer of the panel.
+func (panel *Panel) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(panel.content)
+}
+
+// SetOnEnginePathChangedHandler sets the handler called when the user chooses another engine.
+func (panel *Panel) SetOnEnginePathChangedHandler(handler func(path string)) {
+	panel.onEnginePathChanged = handler
+}
+
+// SetOnInfoHandler sets the handler called with each analysis result, so that
+// it can also be shown outside of the panel.
+func (panel *Panel) SetOnInfoHandler(handler func(info engine.Info, sideToMove chess.Color)) {
+	panel.onInfo = handler
+}
+
+// SetOnClearedHandler sets the handler called when the shown analysis is cleared.
+func (panel *Panel) SetOnClearedHandler(handler func()) {
+	panel.onCleared = handler
+}
+
+// SetOnEvaluationBarToggledHandler sets the handler called when the user shows or hides the evaluation bar.
+func (panel *Panel) SetOnEvaluationBarToggledHandler(handler func(shown bool)) {
+	panel.onEvaluationBar = handler
+}
+
+// SetEvaluationBarShown checks or unchecks the evaluation bar option, calling its handler.
+func (panel *Panel) SetEvaluationBarShown(shown bool) {
+	panel.evaluationBarCheck.SetChecked(shown)
+}
+
+// SetEnginePath replaces the engine by the one at the given path, started in the background.
+func (panel *Panel) SetEnginePath(path string) {
+	panel.mutex.Lock()
+	previousEngine := panel.engine
+	panel.engine = nil
+	panel.enginePath = path
+	panel.mutex.Unlock()
+
+	if previousEngine != nil {
+		_ = previousEngine.Close()
+	}
+	panel.clearAnalysis()
+	if path == "" {
+		return
+	}
+
+	panel.engineLabel.SetText(ini.String("analysis.startingEngine"))
+	go func() {
+		startedEngine, err := engine.Start(path)
+		if err != nil {
+			fmt.Println(err)
+			chessboard.QueueEvent(panel.parent, func() {
+				if panel.currentEnginePath() == path {
+					panel.engineLabel.SetText(ini.String("analysis.engineError"))
+				}
+			})
+			return
+		}
+
+		panel.mutex.Lock()
+		// The user may have chosen another engine in the meantime.
+		if panel.enginePath != path {
+			panel.mutex.Unlock()
+			_ = startedEngine.Close()
+			return
+		}
+		panel.engine = startedEngine
+		panel.mutex.Unlock()
+
+		// The widgets are only updated with the events of the window.
+		chessboard.QueueEvent(panel.parent, func() {
+			if panel.currentEnginePath() != path {
+				return
+			}
+			panel.engineLabel.SetText(startedEngine.Name)
+			panel.mutex.Lock()
+			fen := panel.analyzedFen
+			panel.mutex.Unlock()
+			if fen != "" {
+				panel.Analyze(fen)
+			}
+		})
+	}()
+}
+
+func (panel *Panel) currentEnginePath() string {
+	panel.mutex.Lock()
+	defer panel.mutex.Unlock()
+
+	return panel.enginePath
+}
+
+// Analyze starts the analysis of the given position, in Forsyth-Edwards Notation.
+func (panel *Panel) Analyze(fen string) {
+	panel.mutex.Lock()
+	defer panel.mutex.Unlock()
+
+	panel.analyzedFen = fen
+	panel.clearInfo()
+	if panel.engine == nil || !panel.enabled {
+		return
+	}
+
+	sideToMove := chess.White
+	if strings.Contains(fen, " b ") {
+		sideToMove = chess.Black
+	}
+	analyzedEngine := panel.engine
+	err := panel.engine.Analyze(fen, func(info engine.Info) {
+		// The infos come from the engine goroutine, and may be outdated
+		// once the window handles them.
+		chessboard.QueueEvent(panel.parent, func() {
+			panel.mutex.Lock()
+			current := panel.engine == analyzedEngine && panel.analyzedFen == fen && panel.enabled
+			panel.mutex.Unlock()
+			if current {
+				panel.showInfo(info, sideToMove)
+			}
+		})
+	})
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
+// Stop stops the analysis, until Analyze is called again.
+func (panel *Panel) Stop() {
+	panel.mutex.Lock()
+	defer panel.mutex.Unlock()
+
+	panel.analyzedFen = ""
+	if panel.engine != nil {
+		err := panel.engine.Stop()
+		if err != nil {
+			fmt.Println(err)
+		}
+	}
+	panel.clearInfo()
+}
+
+// Close quits the engine. It must be called when the window is closed.
+func (panel *Panel) Close() {
+	panel.mutex.Lock()
+	closedEngine := panel.engine
+	panel.engine = nil
+	panel.enginePath = ""
+	panel.mutex.Unlock()
+
+	if closedEngine != nil {
+		err := closedEngine.Close()
+		if err != nil {
+			fmt.Println(err)
+		}
+	}
+}
+
+func (panel *Panel) showInfo(info engine.Info, sideToMove chess.Color) {
+	panel.evaluationLabel.SetText(fmt.Sprintf("%s (%s %d)", engine.FormatScore(info, sideToMove),
+		ini.String("analysis.depth"), info.Depth))
+	panel.lineLabel.SetText(strings.Join(info.Pv, " "))
+	if panel.onInfo != nil {
+		panel.onInfo(info, sideToMove)
+	}
+}
+
+func (panel *Panel) clearInfo() {
+	panel.evaluationLabel.SetText("")
+	panel.lineLabel.SetText("")
+	if panel.onCleared != nil {
+		panel.onCleared()
+	}
+}
+
+func (panel *Panel) clearAnalysis() {
+	panel.engineLabel.SetText(ini.String("analysis.noEngine"))
+	panel.clearInfo()
+}
+
+func (panel *Panel) setEnabled(enabled bool) {
+	panel.mutex.Lock()
+	panel.enabled = enabled
+	fen := panel.analyzedFen
+	panel.mutex.Unlock()
+
+	if enabled {
+		if fen != "" {
+			panel.Analyze(fen)
+		}
+		return
+	}
+
+	panel.mutex.Lock()
+	if panel.engine != nil {
+		_ = panel.engine.Stop()
+	}
+	panel.mutex.Unlock()
+	panel.clearInfo()
+}
+
+func (panel *Panel) chooseEngine() {
+	openFileDialog := dialog.NewFileOpen(func(fileData fyne.URIReadCloser, err error) {
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if fileData == nil {
+			return
+		}
+		_ = fileData.Close()
+
+		path := fileData.URI().Path()
+		panel.SetEnginePath(path)
+		if panel.onEnginePathChanged != nil {
+			panel.onEnginePathChanged(path)
+		}
+	}, panel.parent)
+	openFileDialog.Show()
+}
@@ -4,7 +4,7 @@ import (
 	"fmt"
 	"image/color"
 	"math"
-	"strings"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
@@ -46,15 +46,30 @@ const (
 	Draw
 )
 
+// PgnResult returns the game termination marker matching the status: 1-0, 0-1, 1/2-1/2 or *.
+func (status GameEndStatus) PgnResult() string {
+	switch status {
+	case WhiteWon:
+		return "1-0"
+	case BlackWon:
+		return "0-1"
+	case Draw:
+		return "1/2-1/2"
+	default:
+		return "*"
+	}
+}
+
 type lastMove struct {
 	originCell commonTypes.Cell
 	targetCell commonTypes.Cell
 
-	baseline       canvas.Line
-	leftArrowLine  canvas.Line
-	rightArrowLine canvas.Line
+	arrowLines []fyne.CanvasObject
 }
 
+// lastMoveArrowColor is the color of the arrow of the last move.
+var lastMoveArrowColor = color.RGBA{100, 90, 200, 0xff}
+
 type movedPiece struct {
 	location   fyne.Position
 	pieceValue chess.Piece
@@ -70,10 +85,24 @@ type ChessBoard struct {
 	parent    *fyne.Window
 	game      chess.Game
 	blackSide BlackSide
+	theme     Theme
+	userSide  chess.Color
 	length    float32
 	lastMove  *lastMove
+	overlays  []*overlayLayer
+	drawings  []commonTypes.Drawing
+
+	displayMode      DisplayMode
+	piecesRevealed   bool
+	revealTimer      *time.Timer
+	revealGeneration int
+
+	timeControl TimeControl
+	clock       *gameClock
 
 	movedPiece          *movedPiece
+	selectedCell        *commonTypes.Cell
+	drawingStartCell    *commonTypes.Cell
 	gameInProgress      bool
 	dragndropInProgress bool
 	pendingPromotion    bool
@@ -83,7 +112,12 @@ type ChessBoard struct {
 	onBlackWin                   func()
 	onDraw                       func()
 	onMoveDone                   func(moveData commonTypes.GameMove)
+	onMoveValidation             func(move *chess.Move) bool
 	onRequestLastHistoryPosition func()
+	onDrawingsChanged            func(drawings []commonTypes.Drawing)
+	onClockTick                  func(white time.Duration, black time.Duration)
+	onTimeOut                    func(side chess.Color) *chess.Move
+	onTimeLost                   func(side chess.Color)
 
 	pieces             [8][8]*canvas.Image
 	positionForHistory string
@@ -93,7 +127,7 @@ type ChessBoard struct {
 func (board *ChessBoard) CreateRenderer() fyne.WidgetRenderer {
 	board.ExtendBaseWidget(board)
 
-	background := canvas.NewRectangle(color.NRGBA{R: 20, G: 110, B: 200, A: 0xff})
+	background := canvas.NewRectangle(board.theme.Colors.Border)
 	cells := [8][8]*canvas.Rectangle{}
 	pieces := [8][8]*canvas.Image{}
 	filesCoords := [2][8]*canvas.Text{}
@@ -117,11 +151,33 @@ func (board *ChessBoard) CreateRenderer() fyne.WidgetRenderer {
 	}
 }
 
+// EndStatus returns the finished status of the last game played on the board.
+func (board *ChessBoard) EndStatus() GameEndStatus {
+	switch board.game.Outcome() {
+	case chess.WhiteWon:
+		return WhiteWon
+	case chess.BlackWon:
+		return BlackWon
+	case chess.Draw:
+		return Draw
+	default:
+		return NotFinished
+	}
+}
+
 // GameInProgress says if the game is in progress in the chess board widget.
 func (board *ChessBoard) GameInProgress() bool {
 	return board.gameInProgress
 }
 
+// Fen returns the position shown on the board, in Forsyth-Edwards Notation.
+func (board *ChessBoard) Fen() string {
+	if !board.gameInProgress && board.positionForHistory != "" {
+		return board.positionForHistory
+	}
+	return board.game.Position().String()
+}
+
 // RequestHistoryPosition tries to set the requested position, if not in progress.
 // Returns true if the data could be processed (game not in progress), false otherwise.
 func (board *ChessBoard) RequestHistoryPosition(position commonTypes.GameMove) bool {
@@ -130,7 +186,7 @@ func (board *ChessBoard) RequestHistoryPosition(position commonTypes.GameMove) b
 
 		board.updatePieces()
 		board.updateLastMoveArrow(position)
-		board.Refresh()
+		board.SetDrawings(position.Drawings)
 		return true
 	}
 
@@ -157,6 +213,13 @@ func (board *ChessBoard) SetOnMoveDoneHandler(handler func(moveData commonTypes.
 	board.onMoveDone = handler
 }
 
+// SetOnMoveValidationHandler sets the handler checking the moves of the user
+// before they are committed on the chess board widget.
+// It should return whether the move is accepted.
+func (board *ChessBoard) SetOnMoveValidationHandler(handler func(move *chess.Move) bool) {
+	board.onMoveValidation = handler
+}
+
 // SetOnRequestLastHistoryPositionHandler sets the handler for requesting last
 // history position loading for the chess board widget.
 func (board *ChessBoard) SetOnRequestLastHistoryPositionHandler(handler func()) {
@@ -165,58 +228,37 @@ func (board *ChessBoard) SetOnRequestLastHistoryPositionHandler(handler func())
 
 // StopGame stops the current game.
 func (board *ChessBoard) StopGame() {
+	board.stopClock()
 	board.gameInProgress = false
+	board.selectedCell = nil
 	if board.onRequestLastHistoryPosition != nil {
 		board.onRequestLastHistoryPosition()
 	}
 }
 
 func (board *ChessBoard) updateLastMoveArrow(position commonTypes.GameMove) {
+	// A position without move, such as the start position, has no arrow.
+	if position.LastMoveOriginCell == position.LastMoveTargetCell {
+		board.lastMove = nil
+		return
+	}
+	if board.lastMove == nil {
+		board.lastMove = &lastMove{}
+	}
 	board.lastMove.originCell = position.LastMoveOriginCell
 	board.lastMove.targetCell = position.LastMoveTargetCell
 	board.LayoutLastMoveArrowIfNeeded(board.Size())
 }
 
-func imageResourceFromPiece(piece chess.Piece) fyne.StaticResource {
-	var result fyne.StaticResource
-
-	switch piece {
-	case chess.WhitePawn:
-		result = *resourceChessplt45Svg
-	case chess.WhiteKnight:
-		result = *resourceChessnlt45Svg
-	case chess.WhiteBishop:
-		result = *resourceChessblt45Svg
-	case chess.WhiteRook:
-		result = *resourceChessrlt45Svg
-	case chess.WhiteQueen:
-		result = *resourceChessqlt45Svg
-	case chess.WhiteKing:
-		result = *resourceChessklt45Svg
-	case chess.BlackPawn:
-		result = *resourceChesspdt45Svg
-	case chess.BlackKnight:
-		result = *resourceChessndt45Svg
-	case chess.BlackBishop:
-		result = *resourceChessbdt45Svg
-	case chess.BlackRook:
-		result = *resourceChessrdt45Svg
-	case chess.BlackQueen:
-		result = *resourceChessqdt45Svg
-	case chess.BlackKing:
-		result = *resourceChesskdt45Svg
-	}
-
-	return result
-}
-
-// NewChessBoard creates a new chess board.
+// NewChessBoard creates a new chess board. The board grows with the widget,
+// length being its minimum length.
 func NewChessBoard(length float32, parent *fyne.Window) *ChessBoard {
 	customFen, _ := chess.FEN("8/8/8/8/8/8/8/8 w - - 0 1")
 
 	chessBoard := &ChessBoard{
 		length:    length,
 		blackSide: BlackAtTop,
+		theme:     DefaultTheme(),
 		game:      *chess.NewGame(chess.UseNotation(chess.LongAlgebraicNotation{}), customFen),
 		parent:    parent,
 	}
@@ -232,11 +274,18 @@ func (board *ChessBoard) NewGame(startPositionFen string) {
 	board.game = *chess.NewGame(chess.UseNotation(chess.LongAlgebraicNotation{}), startFen)
 	board.gameInProgress = true
 	board.lastMove = nil
+	board.selectedCell = nil
 	board.pendingPromotion = false
 	board.positionForHistory = ""
 
+	if board.displayMode == ShowPiecesAfterMove {
+		board.revealPieces()
+	} else {
+		board.hideRevealedPieces()
+	}
 	board.updatePieces()
-	board.Refresh()
+	board.SetDrawings(nil)
+	board.startClock()
 }
 
 // SetOrientation sets the orientation of the board, putting the black side at the requested side.
@@ -245,6 +294,50 @@ func (board *ChessBoard) SetOrientation(orientation BlackSide) {
 	board.Refresh()
 }
 
+// Orientation returns the side where the black side is on the board.
+func (board *ChessBoard) Orientation() BlackSide {
+	return board.blackSide
+}
+
+// SetTheme changes the colors of the board and the pictures of its pieces.
+func (board *ChessBoard) SetTheme(theme Theme) {
+	board.theme = theme
+	board.updatePieces()
+	board.Refresh()
+}
+
+// Theme returns the colors of the board and the pictures of its pieces.
+func (board *ChessBoard) Theme() Theme {
+	return board.theme
+}
+
+// SetUserSide sets the side whose pieces the user is allowed to move.
+// chess.NoColor lets the user move the pieces of both sides.
+func (board *ChessBoard) SetUserSide(side chess.Color) {
+	board.userSide = side
+	// The display mode may hide the pieces of the other side.
+	board.updatePieces()
+	board.Refresh()
+}
+
+// PlayMove plays the given move on behalf of the user's opponent, without
+// going through the move validation handler.
+// Returns true if the move could be played (legal move and game in progress), false otherwise.
+func (board *ChessBoard) PlayMove(move *chess.Move) bool {
+	if !board.gameInProgress || board.pendingPromotion || move == nil {
+		return false
+	}
+
+	for _, currentMove := range board.game.ValidMoves() {
+		if currentMove.String() == move.String() {
+			board.commitMove(currentMove)
+			return true
+		}
+	}
+
+	return false
+}
+
 // Dragged handles the dragged event for the chess board.
 func (board *ChessBoard) Dragged(event *fyne.DragEvent) {
 	if board.pendingPromotion {
@@ -278,6 +371,48 @@ func (board *ChessBoard) DragEnd() {
 		return
 	}
 
+	board.completeMovedPieceMove()
+}
+
+// Tapped selects a piece of the user on a first tap, highlighting its legal
+// target cells, and plays the move to the tapped cell on a second tap. Tapping
+// any other cell cancels the selection.
+func (board *ChessBoard) Tapped(event *fyne.PointEvent) {
+	if !board.gameInProgress || board.pendingPromotion || board.dragndropInProgress {
+		return
+	}
+
+	cell, inBounds := board.cellAt(event.Position)
+
+	if board.selectedCell == nil {
+		if inBounds && board.userCanMove(board.pieceAt(cell)) {
+			board.selectedCell = &cell
+			board.Refresh()
+		}
+		return
+	}
+
+	startCell := *board.selectedCell
+	board.selectedCell = nil
+	if !inBounds || !board.isLegalTarget(startCell, cell) {
+		board.Refresh()
+		return
+	}
+
+	board.movedPiece = &movedPiece{
+		location:   fyne.Position{X: -1000, Y: -1000},
+		pieceValue: board.pieceAt(startCell),
+		startCell:  startCell,
+		endCell:    cell,
+	}
+	board.completeMovedPieceMove()
+}
+
+// completeMovedPieceMove plays the move of the moved piece, from its start cell
+// to its end cell, going through the promotion dialog if needed.
+func (board *ChessBoard) completeMovedPieceMove() {
+	rank := board.movedPiece.endCell.Rank
+
 	rank1 := int8(0)
 	rank8 := int8(7)
 
@@ -318,59 +453,64 @@ func (board *ChessBoard) DragEnd() {
 		return
 	}
 
+	board.commitUserMove(moveToBeDone)
+}
+
+// commitUserMove plays the given move, if accepted by the validation handler.
+// Returns whether the move has been played.
+func (board *ChessBoard) commitUserMove(moveToBeDone *chess.Move) bool {
+	moveAccepted := board.onMoveValidation == nil || board.onMoveValidation(moveToBeDone)
+	if !moveAccepted {
+		board.resetDragAndDrop()
+		board.Refresh()
+		return false
+	}
+
+	board.commitMove(moveToBeDone)
+	return true
+}
+
+// commitMove plays the given move, and notifies the move done and game end handlers.
+func (board *ChessBoard) commitMove(moveToBeDone *chess.Move) {
 	moveSan := chess.AlgebraicNotation{}.Encode(board.game.Position(), moveToBeDone)
-	moveFan := convertSanToFan(moveSan, board.game.Position().Turn() == chess.White)
+	moveFan := commonTypes.ConvertSanToFan(moveSan, board.game.Position().Turn() == chess.White)
 	wasBlackToMove := board.game.Position().Turn() == chess.Black
+	originCell := commonTypes.Cell{File: int8(moveToBeDone.S1().File()), Rank: int8(moveToBeDone.S1().Rank())}
+	targetCell := commonTypes.Cell{File: int8(moveToBeDone.S2().File()), Rank: int8(moveToBeDone.S2().Rank())}
 
+	board.selectedCell = nil
 	err := board.game.Move(moveToBeDone)
 	positionAfterMove := board.game.Position().String()
 	if err == nil {
 		board.lastMove = &lastMove{
-			originCell: board.movedPiece.startCell,
-			targetCell: board.movedPiece.endCell,
+			originCell: originCell,
+			targetCell: targetCell,
 		}
+		// The clock must have switched when the handler learns the move, as it may stop the game.
+		board.switchClock()
 		if board.onMoveDone != nil {
 			moveData := commonTypes.GameMove{
 				Fan:                moveFan,
+				San:                moveSan,
 				Fen:                positionAfterMove,
-				LastMoveOriginCell: board.movedPiece.startCell,
-				LastMoveTargetCell: board.movedPiece.endCell,
+				LastMoveOriginCell: originCell,
+				LastMoveTargetCell: targetCell,
 				IsBlackMove:        wasBlackToMove,
 			}
 			board.onMoveDone(moveData)
 		}
 	}
 	board.resetDragAndDrop()
+	if err == nil && board.displayMode == ShowPiecesAfterMove {
+		board.revealPieces()
+	}
 	board.updatePieces()
-	board.Refresh()
+	board.SetDrawings(nil)
 
 	board.handleGameEndedStatus()
-}
-
-func convertSanToFan(san string, whiteMove bool) string {
-	fan := san
-	var kingChange, queenChange, rookChange, bishopChange, knightChange string
-	if whiteMove {
-		kingChange = "\u2654"
-		queenChange = "\u2655"
-		rookChange = "\u2656"
-		bishopChange = "\u2657"
-		knightChange = "\u2658"
-	} else {
-		kingChange = "\u265A"
-		queenChange = "\u265B"
-		rookChange = "\u265C"
-		bishopChange = "\u265D"
-		knightChange = "\u265E"
+	if !board.gameInProgress {
+		board.stopClock()
 	}
-
-	fan = strings.ReplaceAll(fan, "K", kingChange)
-	fan = strings.ReplaceAll(fan, "Q", queenChange)
-	fan = strings.ReplaceAll(fan, "R", rookChange)
-	fan = strings.ReplaceAll(fan, "B", bishopChange)
-	fan = strings.ReplaceAll(fan, "N", knightChange)
-
-	return fan
 }
 
 // ClaimDraw emits a draw claim (for 3-folds repetitions, or for 50-moves rule).
@@ -412,47 +552,32 @@ func (board *ChessBoard) startDragAndDrop(event *fyne.DragEvent) {
 		return
 	}
 
-	cellsLength := float64(board.length) / 9
-	halfCellsLength := float64(cellsLength) / 2
+	halfCellsLength := board.geometry(board.Size()).cellsLength / 2
 
 	position := event.Position
-	// This is really needed to be coded as is !
-	// First file and rank, then bounds test, then adjust values with the board orientation
-	file := int8(math.Floor((float64(position.X)-halfCellsLength) / cellsLength))
-	rank := int8(math.Floor((float64(position.Y)-halfCellsLength) / cellsLength))
-
-	inBounds := file >= 0 && file <= 7 && rank >= 0 && rank <= 7
+	cell, inBounds := board.cellAt(position)
 	if !inBounds {
 		return
 	}
+	file, rank := cell.File, cell.Rank
 
-	if board.blackSide == BlackAtTop {
-		rank = 7 - rank
-	} else {
-		file = 7 - file
-	}
-
-	square := chess.Square(file + 8*rank)
-	pieceValue := board.game.Position().Board().Piece(square)
-
-	pieceSide := pieceValue.Color()
-	pieceBelongsToSideInTurn := pieceSide == board.game.Position().Turn()
-	if !pieceBelongsToSideInTurn {
-		return
-	}
-
-	if pieceValue == chess.NoPiece {
+	pieceValue := board.pieceAt(cell)
+	if !board.userCanMove(pieceValue) {
 		return
 	}
 
+	board.selectedCell = nil
 	board.dragndropInProgress = true
 
-	imageResource := imageResourceFromPiece(pieceValue)
-	image := canvas.NewImageFromResource(&imageResource)
+	image := canvas.NewImageFromResource(board.theme.Pieces.Picture(pieceValue))
 	image.FillMode = canvas.ImageFillContain
+	// A hidden piece stays hidden while dragged.
+	if !board.pieceVisible(pieceValue) {
+		image.Hide()
+	}
 	movedPiece := movedPiece{}
 	movedPiece.pieceImage = image
-	movedPiece.location = fyne.Position{X: position.X - float32(halfCellsLength), Y: position.Y - float32(halfCellsLength)}
+	movedPiece.location = fyne.Position{X: position.X - halfCellsLength, Y: position.Y - halfCellsLength}
 	movedPiece.startCell = commonTypes.Cell{File: file, Rank: rank}
 	movedPiece.endCell = commonTypes.Cell{File: file, Rank: rank}
 	movedPiece.pieceValue = pieceValue
@@ -460,31 +585,66 @@ func (board *ChessBoard) startDragAndDrop(event *fyne.DragEvent) {
 	board.Refresh()
 }
 
+// cellAt returns the cell under the given point of the board, and whether
+// the point is inside the cells.
+func (board *ChessBoard) cellAt(position fyne.Position) (commonTypes.Cell, bool) {
+	return board.geometry(board.Size()).cellAt(position)
+}
+
+func (board *ChessBoard) pieceAt(cell commonTypes.Cell) chess.Piece {
+	square := chess.Square(cell.File + 8*cell.Rank)
+	return board.game.Position().Board().Piece(square)
+}
+
+// userCanMove says whether the given piece belongs to the side in turn, and to the user.
+func (board *ChessBoard) userCanMove(pieceValue chess.Piece) bool {
+	if pieceValue == chess.NoPiece {
+		return false
+	}
+
+	pieceSide := pieceValue.Color()
+	pieceBelongsToSideInTurn := pieceSide == board.game.Position().Turn()
+	pieceBelongsToUser := board.userSide == chess.NoColor || pieceSide == board.userSide
+
+	return pieceBelongsToSideInTurn && pieceBelongsToUser
+}
+
+// isLegalTarget says whether a legal move goes from the start cell to the target cell.
+func (board *ChessBoard) isLegalTarget(startCell commonTypes.Cell, targetCell commonTypes.Cell) bool {
+	for _, currentMove := range board.game.ValidMoves() {
+		if int8(currentMove.S1().File()) == startCell.File &&
+			int8(currentMove.S1().Rank()) == startCell.Rank &&
+			int8(currentMove.S2().File()) == targetCell.File &&
+			int8(currentMove.S2().Rank()) == targetCell.Rank {
+			return true
+		}
+	}
+	return false
+}
+
 func (board *ChessBoard) updateDragAndDrop(event *fyne.DragEvent) {
 	if !board.gameInProgress {
 		return
 	}
 
-	cellsLength := float64(board.length) / 9
-	halfCellsLength := float64(cellsLength) / 2
+	halfCellsLength := board.geometry(board.Size()).cellsLength / 2
 
 	position := event.Position
-	var file, rank int8
-	if board.blackSide == BlackAtTop {
-		file = int8(math.Floor((float64(position.X)-halfCellsLength) / float64(cellsLength)))
-		rank = int8(7 - int8(math.Floor((float64(position.Y)-halfCellsLength) /float64(cellsLength))))
-	} else {
-		file = int8(7 - int8(math.Floor((float64(position.X)-halfCellsLength)/float64(cellsLength))))
-		rank = int8(math.Floor((float64(position.Y)-halfCellsLength) / float64(cellsLength)))
+	cell, inBounds := board.cellAt(position)
+	if !inBounds {
+		cell = commonTypes.Cell{File: -1, Rank: -1}
 	}
 
-	board.movedPiece.location = fyne.Position{X: float32(position.X) - float32(halfCellsLength), Y: float32(position.Y) - float32(halfCellsLength)}
-	board.movedPiece.endCell = commonTypes.Cell{File: file, Rank: rank}
+	board.movedPiece.location = fyne.Position{X: position.X - halfCellsLength, Y: position.Y - halfCellsLength}
+	board.movedPiece.endCell = cell
 	board.Refresh()
 }
 
 func (board *ChessBoard) resetDragAndDrop() {
 	board.dragndropInProgress = false
+	if board.movedPiece == nil {
+		return
+	}
 	board.movedPiece.location = fyne.Position{X: -1000, Y: -1000}
 	board.movedPiece.startCell = commonTypes.Cell{File: -1, Rank: -1}
 }
@@ -505,9 +665,9 @@ func (board *ChessBoard) getMatchingMove(promotionPiece chess.PieceType) *chess.
 	return nil
 }
 
-func (board ChessBoard) buildCellsAndPieces(cells *[8][8]*canvas.Rectangle, pieces *[8][8]*canvas.Image) {
-	whiteCellColor := color.RGBA{255, 206, 158, 0xff}
-	blackCellColor := color.RGBA{209, 139, 71, 0xff}
+func (board *ChessBoard) buildCellsAndPieces(cells *[8][8]*canvas.Rectangle, pieces *[8][8]*canvas.Image) {
+	whiteCellColor := board.theme.Colors.WhiteCells
+	blackCellColor := board.theme.Colors.BlackCells
 
 	for line := 0; line < 8; line++ {
 		for col := 0; col < 8; col++ {
@@ -524,9 +684,8 @@ func (board ChessBoard) buildCellsAndPieces(cells *[8][8]*canvas.Rectangle, piec
 
 			square := chess.Square(col + 8*line)
 			pieceValue := board.game.Position().Board().Piece(square)
-			if pieceValue != chess.NoPiece {
-				imageResource := imageResourceFromPiece(pieceValue)
-				image := canvas.NewImageFromResource(&imageResource)
+			if pieceValue != chess.NoPiece && board.pieceVisible(pieceValue) {
+				image := canvas.NewImageFromResource(board.theme.Pieces.Picture(pieceValue))
 				image.FillMode = canvas.ImageFillContain
 
 				pieces[line][col] = image
@@ -535,8 +694,8 @@ func (board ChessBoard) buildCellsAndPieces(cells *[8][8]*canvas.Rectangle, piec
 	}
 }
 
-func (board ChessBoard) buildFilesCoordinates(filesCoords *[2][8]*canvas.Text) {
-	coordsColor := color.RGBA{255, 199, 0, 0xff}
+func (board *ChessBoard) buildFilesCoordinates(filesCoords *[2][8]*canvas.Text) {
+	coordsColor := board.theme.Colors.Coordinates
 	asciiLowerA := 97
 
 	for file := 0; file < 8; file++ {
@@ -549,8 +708,8 @@ func (board ChessBoard) buildFilesCoordinates(filesCoords *[2][8]*canvas.Text) {
 	}
 }
 
-func (board ChessBoard) buildRanksCoordinates(ranksCoords *[2][8]*canvas.Text) {
-	coordsColor := color.RGBA{255, 199, 0, 0xff}
+func (board *ChessBoard) buildRanksCoordinates(ranksCoords *[2][8]*canvas.Text) {
+	coordsColor := board.theme.Colors.Coordinates
 	asciiOne := 49
 
 	for rank := 0; rank < 8; rank++ {
@@ -564,7 +723,7 @@ func (board ChessBoard) buildRanksCoordinates(ranksCoords *[2][8]*canvas.Text) {
 
 }
 
-func (board ChessBoard) buildPlayerTurn() *canvas.Circle {
+func (board *ChessBoard) buildPlayerTurn() *canvas.Circle {
 	var playerTurnColor color.Color
 	gameTurn := board.game.Position().Turn()
 	if gameTurn == chess.White {
@@ -591,9 +750,8 @@ func (board *ChessBoard) updatePieces() {
 
 			square := chess.Square(col + 8*line)
 			pieceValue := board.game.Position().Board().Piece(square)
-			if pieceValue != chess.NoPiece {
-				imageResource := imageResourceFromPiece(pieceValue)
-				image := canvas.NewImageFromResource(&imageResource)
+			if pieceValue != chess.NoPiece && board.pieceVisible(pieceValue) {
+				image := canvas.NewImageFromResource(board.theme.Pieces.Picture(pieceValue))
 				image.FillMode = canvas.ImageFillContain
 
 				board.pieces[line][col] = image
@@ -603,51 +761,29 @@ func (board *ChessBoard) updatePieces() {
 }
 
 func (board *ChessBoard) commitPromotion(pieceType chess.PieceType) {
-	if pieceType == chess.Pawn || pieceType == chess.King {
+	// The promotion may have been cancelled by a time out.
+	if !board.pendingPromotion || pieceType == chess.Pawn || pieceType == chess.King {
 		return
 	}
 
 	moveToBeDone := board.getMatchingMove(pieceType)
+	board.pendingPromotion = false
 
 	if moveToBeDone == nil {
-		board.pendingPromotion = false
 		board.resetDragAndDrop()
 		board.Refresh()
+		return
 	}
 
-	moveSan := chess.AlgebraicNotation{}.Encode(board.game.Position(), moveToBeDone)
-	moveFan := convertSanToFan(moveSan, board.game.Position().Turn() == chess.White)
-	wasBlackToMove := board.game.Position().Turn() == chess.Black
-
-	err := board.game.Move(moveToBeDone)
-	positionAfterMove := board.game.Position().String()
-
-	if err == nil {
-		board.lastMove = &lastMove{
-			originCell: board.movedPiece.startCell,
-			targetCell: board.movedPiece.endCell,
-		}
-		if board.onMoveDone != nil {
-			moveData := commonTypes.GameMove{
-				Fan:                moveFan,
-				Fen:                positionAfterMove,
-				LastMoveOriginCell: board.movedPiece.startCell,
-				LastMoveTargetCell: board.movedPiece.endCell,
-				IsBlackMove:        wasBlackToMove,
-			}
-			board.onMoveDone(moveData)
-		}
-	}
-
-	board.pendingPromotion = false
-	board.resetDragAndDrop()
-	board.updatePieces()
-	board.Refresh()
-
-	board.handleGameEndedStatus()
+	board.commitUserMove(moveToBeDone)
 }
 
 func (board *ChessBoard) handleGameEndedStatus() {
+	// The game may already have been stopped by a move handler.
+	if !board.gameInProgress {
+		return
+	}
+
 	gameOutcome := board.game.Outcome()
 	switch gameOutcome {
 	case chess.WhiteWon:
@@ -682,20 +818,21 @@ func (board *ChessBoard) launchPromotionDialog() {
 	title := ini.String("promotionDialog.title")
 	dismiss := ini.String("promotionDialog.dismissButton")
 
-	var queenRes, rookRes, bishopRes, knightRes *fyne.StaticResource
+	pieces := board.theme.Pieces
+	var queenRes, rookRes, bishopRes, knightRes fyne.Resource
 	if board.game.Position().Turn() == chess.White {
-		queenRes = resourceChessqlt45Svg
-		rookRes = resourceChessrlt45Svg
-		bishopRes = resourceChessblt45Svg
-		knightRes = resourceChessnlt45Svg
+		queenRes = pieces.Picture(chess.WhiteQueen)
+		rookRes = pieces.Picture(chess.WhiteRook)
+		bishopRes = pieces.Picture(chess.WhiteBishop)
+		knightRes = pieces.Picture(chess.WhiteKnight)
 	} else {
-		queenRes = resourceChessqdt45Svg
-		rookRes = resourceChessrdt45Svg
-		bishopRes = resourceChessbdt45Svg
-		knightRes = resourceChessndt45Svg
+		queenRes = pieces.Picture(chess.BlackQueen)
+		rookRes = pieces.Picture(chess.BlackRook)
+		bishopRes = pieces.Picture(chess.BlackBishop)
+		knightRes = pieces.Picture(chess.BlackKnight)
 	}
 
-	cellsLength := float32(board.length) / 9
+	cellsLength := board.geometry(board.Size()).cellsLength
 	commonButtonsSize := fyne.NewSize(cellsLength, cellsLength)
 
 	queenButton := NewIconButton(queenRes, commonButtonsSize, func() {
@@ -734,34 +871,31 @@ func (board *ChessBoard) launchPromotionDialog() {
 }
 
 func (board *ChessBoard) LayoutLastMoveArrowIfNeeded(size fyne.Size) {
-	minSize := float32(math.Min(float64(size.Width), float64(size.Height)))
-	cellsLength := float32(minSize / 9.0)
-
 	if board.lastMove != nil {
-		var xa, ya, xb, yb float32
-		if board.blackSide == BlackAtTop {
-			xa = cellsLength + float32(board.lastMove.originCell.File)*cellsLength
-			ya = cellsLength + float32(7-board.lastMove.originCell.Rank)*cellsLength
-			xb = cellsLength + float32(board.lastMove.targetCell.File)*cellsLength
-			yb = cellsLength + float32(7-board.lastMove.targetCell.Rank)*cellsLength
-		} else {
-			xa = cellsLength + float32(7-board.lastMove.originCell.File)*cellsLength
-			ya = cellsLength + float32(board.lastMove.originCell.Rank)*cellsLength
-			xb = cellsLength + float32(7-board.lastMove.targetCell.File)*cellsLength
-			yb = cellsLength + float32(board.lastMove.targetCell.Rank)*cellsLength
-		}
-		arrowWidth := cellsLength * float32(0.2)
-		arrowLengthPercentage := float32(0.25)
-		lineThickness := cellsLength * float32(0.1)
-		board.makeArrow(xa, ya, xb, yb, arrowWidth, arrowLengthPercentage, lineThickness)
+		board.lastMove.arrowLines = board.makeCellsArrow(size, board.lastMove.originCell,
+			board.lastMove.targetCell, lastMoveArrowColor)
 	}
 }
 
-// based on http://xymaths.free.fr/Informatique-Programmation/javascript/canvas-dessin-fleche.php
-func (board *ChessBoard) makeArrow(xa float32, ya float32, xb float32, yb float32,
-	arrowWidth float32, arrowLengthPercentage float32, lineThickness float32) {
+// makeCellsArrow builds the lines of an arrow going from the center of the
+// origin cell to the center of the target cell.
+func (board *ChessBoard) makeCellsArrow(size fyne.Size, originCell commonTypes.Cell, targetCell commonTypes.Cell,
+	arrowColor color.Color) []fyne.CanvasObject {
+	geometry := board.geometry(size)
+	cellsLength := geometry.cellsLength
+
+	origin := geometry.cellCenter(originCell)
+	target := geometry.cellCenter(targetCell)
+	arrowWidth := cellsLength * float32(0.2)
+	arrowLengthPercentage := float32(0.25)
+	lineThickness := cellsLength * float32(0.1)
+	return makeArrow(origin.X, origin.Y, target.X, target.Y, arrowWidth, arrowLengthPercentage, lineThickness, arrowColor)
+}
 
-	arrowColor := color.RGBA{100, 90, 200, 0xff}
+// based on http://xymaths.free.fr/Informatique-Programmation/javascript/canvas-dessin-fleche.php
+func makeArrow(xa float32, ya float32, xb float32, yb float32,
+	arrowWidth float32, arrowLengthPercentage float32, lineThickness float32,
+	arrowColor color.Color) []fyne.CanvasObject {
 
 	deltaX := float64(xb - xa)
 	deltaY := float64(yb - ya)
@@ -777,22 +911,20 @@ func (board *ChessBoard) makeArrow(xa float32, ya float32, xb float32, yb float3
 	xe := xc - float32(arrowWidth*(ya-yb))/abLength
 	ye := yc - float32(arrowWidth*(xb-xa))/abLength
 
-	baseLine := *canvas.NewLine(arrowColor)
+	baseLine := canvas.NewLine(arrowColor)
 	baseLine.StrokeWidth = lineThickness
 	baseLine.Position1 = fyne.NewPos(xa, ya)
 	baseLine.Position2 = fyne.NewPos(xb, yb)
 
-	arrowLine1 := *canvas.NewLine(arrowColor)
+	arrowLine1 := canvas.NewLine(arrowColor)
 	arrowLine1.StrokeWidth = lineThickness
 	arrowLine1.Position1 = fyne.NewPos(xd, yd)
 	arrowLine1.Position2 = fyne.NewPos(xb, yb)
 
-	arrowLine2 := *canvas.NewLine(arrowColor)
+	arrowLine2 := canvas.NewLine(arrowColor)
 	arrowLine2.StrokeWidth = lineThickness
 	arrowLine2.Position1 = fyne.NewPos(xb, yb)
 	arrowLine2.Position2 = fyne.NewPos(xe, ye)
 
-	board.lastMove.baseline = baseLine
-	board.lastMove.leftArrowLine = arrowLine1
-	board.lastMove.rightArrowLine = arrowLine2
+	return []fyne.CanvasObject{baseLine, arrowLine1, arrowLine2}
 }
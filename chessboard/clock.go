@@ -0,0 +1,271 @@
+package chessboard
+
+import (
+	"sync"
+	"time"
+
+	"github.com/notnil/chess"
+)
+
+// TimeControl gives the time the sides have to play. The zero value means no clock.
+type TimeControl struct {
+	// Total is the time of each side for the whole game, Increment being
+	// added to it after each of its moves. A side whose time has run out
+	// loses the game.
+	Total     time.Duration
+	Increment time.Duration
+
+	// PerMove, if not zero, is the time of each move, replacing Total and
+	// Increment. A side whose time has run out goes on with its next move.
+	PerMove time.Duration
+}
+
+// Enabled says whether the time control runs a clock.
+func (control TimeControl) Enabled() bool {
+	return control.Total > 0 || control.PerMove > 0
+}
+
+// clockTickInterval is the time between two updates of the clocks.
+const clockTickInterval = 100 * time.Millisecond
+
+// gameClock counts down the time of the side to move, with a ticker running
+// in the background from start until stop.
+type gameClock struct {
+	mutex   sync.Mutex
+	control TimeControl
+
+	// remaining is the time of each side when the clock was last started or stopped.
+	remaining map[chess.Color]time.Duration
+	side      chess.Color
+	turnStart time.Time
+	// turn counts the switches of side, telling apart the turns of a side.
+	turn int
+
+	// done is closed to stop the ticker, and nil while the clock is stopped.
+	done chan struct{}
+}
+
+func newGameClock(control TimeControl, side chess.Color) *gameClock {
+	startTime := control.Total
+	if control.PerMove > 0 {
+		startTime = control.PerMove
+	}
+
+	return &gameClock{
+		control:   control,
+		remaining: map[chess.Color]time.Duration{chess.White: startTime, chess.Black: startTime},
+		side:      side,
+	}
+}
+
+// start runs the time of the side to move, calling onTick after each tick, and
+// onTimeOut with the turn once the time of the side has run out. The clock
+// then runs until stopped, the time of the side staying at zero.
+func (clock *gameClock) start(onTick func(white time.Duration, black time.Duration),
+	onTimeOut func(side chess.Color, turn int)) {
+	clock.mutex.Lock()
+	clock.turnStart = time.Now()
+	done := make(chan struct{})
+	clock.done = done
+	side := clock.side
+	turn := clock.turn
+	clock.mutex.Unlock()
+
+	ticker := time.NewTicker(clockTickInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				white, black := clock.times()
+				onTick(white, black)
+				if (side == chess.White && white > 0) || (side == chess.Black && black > 0) {
+					continue
+				}
+				onTimeOut(side, turn)
+				return
+			}
+		}
+	}()
+}
+
+// stop stops the ticker, keeping the time left to the side to move. It
+// returns false if the clock was already stopped.
+func (clock *gameClock) stop() bool {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+
+	if clock.done == nil {
+		return false
+	}
+	clock.remaining[clock.side] = clock.timeLeft()
+	close(clock.done)
+	clock.done = nil
+	return true
+}
+
+// times returns the current time of each side.
+func (clock *gameClock) times() (white time.Duration, black time.Duration) {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+
+	if clock.side == chess.White {
+		return clock.timeLeft(), clock.remaining[chess.Black]
+	}
+	return clock.remaining[chess.White], clock.timeLeft()
+}
+
+// timeLeft returns the current time of the side to move. The mutex must be locked.
+func (clock *gameClock) timeLeft() time.Duration {
+	timeLeft := clock.remaining[clock.side]
+	if clock.done != nil {
+		timeLeft -= time.Since(clock.turnStart)
+		if timeLeft < 0 {
+			timeLeft = 0
+		}
+	}
+	return timeLeft
+}
+
+// isTimedOut says whether the time of the given side has run out during the
+// given turn, which must still be the current one.
+func (clock *gameClock) isTimedOut(side chess.Color, turn int) bool {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+
+	return clock.side == side && clock.turn == turn && clock.timeLeft() == 0
+}
+
+// switchSide stops the time of the side which has just moved, and gives the
+// turn to the other side.
+func (clock *gameClock) switchSide(sideToMove chess.Color) {
+	clock.stop()
+
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+
+	if clock.control.PerMove > 0 {
+		clock.remaining[sideToMove] = clock.control.PerMove
+	} else {
+		clock.remaining[clock.side] += clock.control.Increment
+	}
+	clock.side = sideToMove
+	clock.turn++
+}
+
+// SetTimeControl sets the time control of the next games, the zero value meaning no clock.
+func (board *ChessBoard) SetTimeControl(control TimeControl) {
+	board.timeControl = control
+}
+
+// SetOnClockTickHandler sets the handler called with the time of each side
+// whenever the clocks change.
+func (board *ChessBoard) SetOnClockTickHandler(handler func(white time.Duration, black time.Duration)) {
+	board.onClockTick = handler
+}
+
+// SetOnTimeOutHandler sets the handler called when the time of a side runs
+// out, which returns the move to play for that side, or nil. A promotion in
+// progress is cancelled.
+func (board *ChessBoard) SetOnTimeOutHandler(handler func(side chess.Color) *chess.Move) {
+	board.onTimeOut = handler
+}
+
+// SetOnTimeLostHandler sets the handler called once the game has been
+// stopped, the time of the given side for the whole game having run out.
+func (board *ChessBoard) SetOnTimeLostHandler(handler func(side chess.Color)) {
+	board.onTimeLost = handler
+}
+
+// startClock starts the clock of a new game, if the time control has one.
+func (board *ChessBoard) startClock() {
+	board.stopClock()
+	if !board.timeControl.Enabled() {
+		return
+	}
+
+	board.clock = newGameClock(board.timeControl, board.game.Position().Turn())
+	board.notifyClockTick(board.clock.times())
+	board.runClock()
+}
+
+// switchClock gives the turn to the side to move, after a move.
+func (board *ChessBoard) switchClock() {
+	if board.clock == nil {
+		return
+	}
+
+	board.clock.switchSide(board.game.Position().Turn())
+	board.notifyClockTick(board.clock.times())
+	board.runClock()
+}
+
+// runClock starts the clock, its ticks and time out being handled with the
+// events of the window, so that they don't race with the moves.
+func (board *ChessBoard) runClock() {
+	clock := board.clock
+	clock.start(func(white time.Duration, black time.Duration) {
+		board.queueEvent(func() {
+			// The ticks of a replaced clock are outdated.
+			if board.clock == clock {
+				board.notifyClockTick(white, black)
+			}
+		})
+	}, func(side chess.Color, turn int) {
+		board.queueEvent(func() {
+			board.timeOut(clock, side, turn)
+		})
+	})
+}
+
+// timeOut stops the clock once the time of the given side has run out during
+// the given turn, and plays the move given by the time out handler, cancelling
+// the promotion in progress if any. Without a time per move, the game is then
+// lost on time. Nothing is done if the clock has been replaced, or the turn
+// played, meanwhile.
+func (board *ChessBoard) timeOut(clock *gameClock, side chess.Color, turn int) {
+	if board.clock != clock || !board.gameInProgress || !clock.isTimedOut(side, turn) {
+		return
+	}
+
+	clock.stop()
+	if board.pendingPromotion {
+		// The dialog cancels the promotion when closed.
+		board.promotionDialog.Hide()
+	}
+	board.selectedCell = nil
+	board.resetDragAndDrop()
+
+	var move *chess.Move
+	if board.onTimeOut != nil {
+		move = board.onTimeOut(side)
+	}
+	if move == nil || !board.PlayMove(move) {
+		board.Refresh()
+	}
+
+	// The played move may have ended the game.
+	if clock.control.PerMove == 0 && board.gameInProgress {
+		board.StopGame()
+		if board.onTimeLost != nil {
+			board.onTimeLost(side)
+		}
+	}
+}
+
+// stopClock stops the clock of the game, if any, for good.
+func (board *ChessBoard) stopClock() {
+	if board.clock == nil {
+		return
+	}
+	board.clock.stop()
+	board.clock = nil
+}
+
+func (board *ChessBoard) notifyClockTick(white time.Duration, black time.Duration) {
+	if board.onClockTick != nil {
+		board.onClockTick(white, black)
+	}
+}
@@ -0,0 +1,119 @@
+package chessboard
+
+import (
+	"testing"
+	"time"
+
+	"github.com/notnil/chess"
+)
+
+func TestSwitchSideGivesTheTimeOfTheNextTurn(t *testing.T) {
+	tests := []struct {
+		name    string
+		control TimeControl
+		// whiteLeft is the time of white when it moves.
+		whiteLeft time.Duration
+
+		white time.Duration
+		black time.Duration
+	}{
+		{name: "total", control: TimeControl{Total: time.Minute},
+			whiteLeft: 40 * time.Second, white: 40 * time.Second, black: time.Minute},
+		{name: "increment", control: TimeControl{Total: time.Minute, Increment: 2 * time.Second},
+			whiteLeft: 40 * time.Second, white: 42 * time.Second, black: time.Minute},
+		{name: "per move", control: TimeControl{PerMove: 10 * time.Second},
+			whiteLeft: 4 * time.Second, white: 4 * time.Second, black: 10 * time.Second},
+	}
+
+	for _, test := range tests {
+		clock := newGameClock(test.control, chess.White)
+		clock.remaining[chess.White] = test.whiteLeft
+		clock.switchSide(chess.Black)
+
+		white, black := clock.times()
+		if white != test.white || black != test.black {
+			t.Errorf("%s: got %v for white and %v for black, expected %v and %v",
+				test.name, white, black, test.white, test.black)
+		}
+		if clock.turn != 1 || clock.side != chess.Black {
+			t.Errorf("%s: got the turn %d of %v, expected the turn 1 of black", test.name, clock.turn, clock.side)
+		}
+	}
+}
+
+func TestPerMoveTimeRestartsAfterEachMove(t *testing.T) {
+	clock := newGameClock(TimeControl{PerMove: 10 * time.Second}, chess.White)
+	clock.switchSide(chess.Black)
+	clock.remaining[chess.Black] = time.Second
+	clock.switchSide(chess.White)
+
+	white, black := clock.times()
+	if white != 10*time.Second || black != time.Second {
+		t.Errorf("got %v for white and %v for black, expected 10s and 1s", white, black)
+	}
+}
+
+func TestClockTimesOutTheSideToMove(t *testing.T) {
+	clock := newGameClock(TimeControl{PerMove: 3 * clockTickInterval}, chess.Black)
+	clock.switchSide(chess.White)
+
+	timeOuts := make(chan chess.Color, 1)
+	turns := make(chan int, 1)
+	clock.start(func(white time.Duration, black time.Duration) {}, func(side chess.Color, turn int) {
+		timeOuts <- side
+		turns <- turn
+	})
+	defer clock.stop()
+
+	select {
+	case side := <-timeOuts:
+		turn := <-turns
+		if side != chess.White || turn != 1 {
+			t.Errorf("got a time out of %v at the turn %d, expected white at the turn 1", side, turn)
+		}
+		if !clock.isTimedOut(side, turn) {
+			t.Error("the clock should be timed out for the turn")
+		}
+		if clock.isTimedOut(side, turn-1) {
+			t.Error("the clock should not be timed out for a previous turn")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the time out has not been notified")
+	}
+}
+
+func TestStoppedClockKeepsItsTime(t *testing.T) {
+	clock := newGameClock(TimeControl{Total: time.Minute}, chess.White)
+	clock.start(func(white time.Duration, black time.Duration) {}, func(side chess.Color, turn int) {})
+	time.Sleep(clockTickInterval)
+	if !clock.stop() {
+		t.Fatal("the running clock should be stopped")
+	}
+	if clock.stop() {
+		t.Error("the stopped clock should not be stopped again")
+	}
+
+	white, _ := clock.times()
+	time.Sleep(clockTickInterval)
+	if laterWhite, _ := clock.times(); laterWhite != white || white >= time.Minute {
+		t.Errorf("got %v then %v for white, expected a constant time below 1m", white, laterWhite)
+	}
+}
+
+func TestFormatClockTime(t *testing.T) {
+	tests := []struct {
+		timeLeft time.Duration
+		text     string
+	}{
+		{timeLeft: 5*time.Minute + 7*time.Second, text: "5:07"},
+		{timeLeft: 10*time.Second + 900*time.Millisecond, text: "0:10"},
+		{timeLeft: 9*time.Second + 400*time.Millisecond, text: "0:09.4"},
+		{timeLeft: 0, text: "0:00.0"},
+	}
+
+	for _, test := range tests {
+		if text := formatClockTime(test.timeLeft); text != test.text {
+			t.Errorf("%v: got %q, expected %q", test.timeLeft, text, test.text)
+		}
+	}
+}
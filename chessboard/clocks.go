@@ -0,0 +1,93 @@
+package chessboard
+
+import (
+	"fmt"
+	"image/color"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// lowTimeColor is the color of a clock with less than lowTime left.
+var lowTimeColor = color.NRGBA{R: 220, G: 40, B: 40, A: 0xff}
+
+// lowTime is the time left from which the clock shows tenths of seconds.
+const lowTime = 10 * time.Second
+
+// Clocks shows the time of both sides, to be put beside the board, each
+// clock on the side of its pieces.
+type Clocks struct {
+	widget.BaseWidget
+
+	blackSide BlackSide
+	white     time.Duration
+	black     time.Duration
+
+	topText    *canvas.Text
+	bottomText *canvas.Text
+}
+
+// NewClocks creates the clocks, both at zero.
+func NewClocks() *Clocks {
+	clocks := &Clocks{
+		blackSide:  BlackAtTop,
+		topText:    canvas.NewText("", theme.ForegroundColor()),
+		bottomText: canvas.NewText("", theme.ForegroundColor()),
+	}
+	clocks.topText.TextSize = 2 * theme.TextSize()
+	clocks.bottomText.TextSize = 2 * theme.TextSize()
+	clocks.ExtendBaseWidget(clocks)
+	clocks.updateTexts()
+
+	return clocks
+}
+
+// SetOrientation puts the clock of black at the requested side, as for the board.
+func (clocks *Clocks) SetOrientation(orientation BlackSide) {
+	clocks.blackSide = orientation
+	clocks.updateTexts()
+}
+
+// SetTimes shows the given times of the sides.
+func (clocks *Clocks) SetTimes(white time.Duration, black time.Duration) {
+	clocks.white, clocks.black = white, black
+	clocks.updateTexts()
+}
+
+// CreateRenderer creates the renderer of the clocks.
+func (clocks *Clocks) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(container.NewVBox(clocks.topText, layout.NewSpacer(), clocks.bottomText))
+}
+
+func (clocks *Clocks) updateTexts() {
+	topTime, bottomTime := clocks.black, clocks.white
+	if clocks.blackSide == BlackAtBottom {
+		topTime, bottomTime = clocks.white, clocks.black
+	}
+	setClockText(clocks.topText, topTime)
+	setClockText(clocks.bottomText, bottomTime)
+}
+
+func setClockText(text *canvas.Text, timeLeft time.Duration) {
+	text.Text = formatClockTime(timeLeft)
+	text.Color = theme.ForegroundColor()
+	if timeLeft < lowTime {
+		text.Color = lowTimeColor
+	}
+	text.Refresh()
+}
+
+// formatClockTime writes the given time as minutes and seconds, with tenths
+// of seconds once it is low.
+func formatClockTime(timeLeft time.Duration) string {
+	if timeLeft < lowTime {
+		return fmt.Sprintf("0:%04.1f", timeLeft.Seconds())
+	}
+	seconds := int(timeLeft.Truncate(time.Second).Seconds())
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
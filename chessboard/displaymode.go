@@ -0,0 +1,104 @@
+package chessboard
+
+import (
+	"time"
+
+	"github.com/notnil/chess"
+)
+
+// DisplayMode tells which pieces the board shows, for blindfold training.
+// The hidden pieces can still be moved, by dragging them or typing their moves.
+type DisplayMode int
+
+const (
+	// ShowAllPieces shows every piece, as usual.
+	ShowAllPieces DisplayMode = iota
+
+	// HideAllPieces hides every piece.
+	HideAllPieces
+
+	// HideOpponentPieces hides the pieces of the user's opponent, or those
+	// of the side not in turn when the user plays both sides.
+	HideOpponentPieces
+
+	// ShowPiecesAfterMove shows every piece for a few seconds after each move, then hides them.
+	ShowPiecesAfterMove
+)
+
+// DisplayModes are the display modes, in the order they are proposed to the user.
+var DisplayModes = []DisplayMode{ShowAllPieces, HideAllPieces, HideOpponentPieces, ShowPiecesAfterMove}
+
+// revealDuration is how long the pieces are shown after a move, or a peek.
+const revealDuration = 3 * time.Second
+
+// SetDisplayMode sets which pieces the board shows.
+func (board *ChessBoard) SetDisplayMode(mode DisplayMode) {
+	board.displayMode = mode
+	board.hideRevealedPieces()
+	board.updatePieces()
+	board.Refresh()
+}
+
+// DisplayMode returns which pieces the board shows.
+func (board *ChessBoard) DisplayMode() DisplayMode {
+	return board.displayMode
+}
+
+// Peek shows every piece for a few seconds, whatever the display mode.
+func (board *ChessBoard) Peek() {
+	board.revealPieces()
+}
+
+// revealPieces shows every piece for revealDuration, then applies the display mode again.
+func (board *ChessBoard) revealPieces() {
+	board.hideRevealedPieces()
+	board.piecesRevealed = true
+	board.updatePieces()
+	board.Refresh()
+
+	// A later reveal stops this timer, but it may have fired already: the
+	// generation tells whether the callback still matches the last reveal.
+	// The pieces are hidden again with the events of the window, which
+	// change the reveal state and the pieces too.
+	board.revealGeneration++
+	generation := board.revealGeneration
+	board.revealTimer = time.AfterFunc(revealDuration, func() {
+		board.queueEvent(func() {
+			if generation != board.revealGeneration {
+				return
+			}
+			board.piecesRevealed = false
+			board.updatePieces()
+			board.Refresh()
+		})
+	})
+}
+
+// hideRevealedPieces cancels the reveal of the pieces in progress, if any.
+func (board *ChessBoard) hideRevealedPieces() {
+	if board.revealTimer != nil {
+		board.revealTimer.Stop()
+		board.revealTimer = nil
+	}
+	board.revealGeneration++
+	board.piecesRevealed = false
+}
+
+// pieceVisible says whether the display mode lets the given piece be shown.
+func (board *ChessBoard) pieceVisible(pieceValue chess.Piece) bool {
+	if board.piecesRevealed {
+		return true
+	}
+
+	switch board.displayMode {
+	case HideAllPieces, ShowPiecesAfterMove:
+		return false
+	case HideOpponentPieces:
+		userSide := board.userSide
+		if userSide == chess.NoColor {
+			userSide = board.game.Position().Turn()
+		}
+		return pieceValue.Color() == userSide
+	}
+	return true
+}
@@ -0,0 +1,96 @@
+package chessboard
+
+import (
+	"image/color"
+
+	"fyne.io/fyne/v2/driver/desktop"
+
+	"github.com/loloof64/chess-pgn-reviser-fyne/commonTypes"
+)
+
+// drawingsOverlay is the name of the overlay showing the drawings of the user.
+const drawingsOverlay = "drawings"
+
+// drawingsColors are the colors painting the drawings.
+var drawingsColors = map[commonTypes.DrawingColor]color.Color{
+	commonTypes.GreenDrawing:  color.NRGBA{21, 120, 27, 0xc0},
+	commonTypes.RedDrawing:    color.NRGBA{136, 32, 32, 0xc0},
+	commonTypes.YellowDrawing: color.NRGBA{230, 143, 0, 0xc0},
+	commonTypes.BlueDrawing:   color.NRGBA{0, 48, 136, 0xc0},
+}
+
+// SetOnDrawingsChangedHandler sets the handler called when the user changes
+// the drawings of the shown position.
+func (board *ChessBoard) SetOnDrawingsChangedHandler(handler func(drawings []commonTypes.Drawing)) {
+	board.onDrawingsChanged = handler
+}
+
+// SetDrawings replaces the arrows and circled cells drawn on the board.
+func (board *ChessBoard) SetDrawings(drawings []commonTypes.Drawing) {
+	board.drawings = append([]commonTypes.Drawing(nil), drawings...)
+	board.drawingStartCell = nil
+
+	overlay := Overlay{}
+	for _, drawing := range board.drawings {
+		drawingColor := drawingsColors[drawing.Color]
+		if drawing.IsCircle() {
+			overlay.Circles = append(overlay.Circles, Circle{Cell: drawing.Origin, Color: drawingColor})
+		} else {
+			overlay.Arrows = append(overlay.Arrows, Arrow{Origin: drawing.Origin, Target: drawing.Target, Color: drawingColor})
+		}
+	}
+	board.SetOverlay(drawingsOverlay, overlay)
+}
+
+// Drawings returns the arrows and circled cells drawn on the board.
+func (board *ChessBoard) Drawings() []commonTypes.Drawing {
+	return append([]commonTypes.Drawing(nil), board.drawings...)
+}
+
+// MouseDown starts a drawing when the secondary button is pressed over a cell.
+func (board *ChessBoard) MouseDown(event *desktop.MouseEvent) {
+	if event.Button != desktop.MouseButtonSecondary {
+		return
+	}
+	cell, inBounds := board.cellAt(event.Position)
+	if !inBounds {
+		board.drawingStartCell = nil
+		return
+	}
+	board.drawingStartCell = &cell
+}
+
+// MouseUp ends the drawing started by MouseDown: releasing the button over the
+// same cell circles it, and releasing it over another cell draws an arrow.
+// The Shift, Alt and Control keys choose red, blue and yellow instead of green.
+func (board *ChessBoard) MouseUp(event *desktop.MouseEvent) {
+	if event.Button != desktop.MouseButtonSecondary || board.drawingStartCell == nil {
+		return
+	}
+	startCell := *board.drawingStartCell
+	board.drawingStartCell = nil
+
+	cell, inBounds := board.cellAt(event.Position)
+	if !inBounds {
+		return
+	}
+
+	drawing := commonTypes.Drawing{Origin: startCell, Target: cell, Color: drawingColorOf(event.Modifier)}
+	board.SetDrawings(commonTypes.ToggleDrawing(board.drawings, drawing))
+	if board.onDrawingsChanged != nil {
+		board.onDrawingsChanged(board.Drawings())
+	}
+}
+
+func drawingColorOf(modifier desktop.Modifier) commonTypes.DrawingColor {
+	switch {
+	case modifier&desktop.ShiftModifier != 0:
+		return commonTypes.RedDrawing
+	case modifier&desktop.AltModifier != 0:
+		return commonTypes.BlueDrawing
+	case modifier&desktop.ControlModifier != 0:
+		return commonTypes.YellowDrawing
+	default:
+		return commonTypes.GreenDrawing
+	}
+}
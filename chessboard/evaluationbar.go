@@ -0,0 +1,141 @@
+package chessboard
+
+import (
+	"image/color"
+	"math"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/widget"
+)
+
+// evaluationBarAnimationDuration is the time the bar takes to reach a new evaluation.
+const evaluationBarAnimationDuration = 400 * time.Millisecond
+
+// EvaluationBar is a vertical bar, to be put beside the board, showing the
+// share of the advantage of each side.
+type EvaluationBar struct {
+	widget.BaseWidget
+
+	width     float32
+	length    float32
+	blackSide BlackSide
+
+	// whiteShare is the displayed part of the bar filled by white, from 0 to 1.
+	whiteShare float32
+	animation  *fyne.Animation
+}
+
+// NewEvaluationBar creates an evaluation bar as high as a board of the given length.
+func NewEvaluationBar(length float32) *EvaluationBar {
+	evaluationBar := &EvaluationBar{
+		width:      20,
+		length:     length,
+		blackSide:  BlackAtTop,
+		whiteShare: 0.5,
+	}
+	evaluationBar.ExtendBaseWidget(evaluationBar)
+
+	return evaluationBar
+}
+
+// SetOrientation puts the black side of the bar at the requested side, as for the board.
+func (evaluationBar *EvaluationBar) SetOrientation(orientation BlackSide) {
+	evaluationBar.blackSide = orientation
+	evaluationBar.Refresh()
+}
+
+// SetEvaluation animates the bar towards the given evaluation, in centipawns
+// from the white point of view.
+func (evaluationBar *EvaluationBar) SetEvaluation(whiteCentipawns int) {
+	evaluationBar.animateTo(whiteShareOf(whiteCentipawns))
+}
+
+// ClearEvaluation animates the bar back to an equal position.
+func (evaluationBar *EvaluationBar) ClearEvaluation() {
+	evaluationBar.animateTo(0.5)
+}
+
+func (evaluationBar *EvaluationBar) animateTo(whiteShare float32) {
+	if evaluationBar.animation != nil {
+		evaluationBar.animation.Stop()
+	}
+
+	startShare := evaluationBar.whiteShare
+	evaluationBar.animation = fyne.NewAnimation(evaluationBarAnimationDuration, func(progress float32) {
+		evaluationBar.whiteShare = startShare + (whiteShare-startShare)*progress
+		evaluationBar.Refresh()
+	})
+	evaluationBar.animation.Curve = fyne.AnimationEaseOut
+	evaluationBar.animation.Start()
+}
+
+// whiteShareOf converts an evaluation to the winning chances of white, so that
+// the bar moves a lot around equality and little once the game is decided.
+func whiteShareOf(whiteCentipawns int) float32 {
+	return float32(1 / (1 + math.Exp(-0.004*float64(whiteCentipawns))))
+}
+
+// CreateRenderer creates the renderer of the bar.
+func (evaluationBar *EvaluationBar) CreateRenderer() fyne.WidgetRenderer {
+	evaluationBar.ExtendBaseWidget(evaluationBar)
+
+	return &evaluationBarRenderer{
+		evaluationBar: evaluationBar,
+		blackPart:     canvas.NewRectangle(color.RGBA{40, 40, 40, 0xff}),
+		whitePart:     canvas.NewRectangle(color.RGBA{240, 240, 240, 0xff}),
+		middleLine:    canvas.NewLine(color.RGBA{255, 20, 30, 0xff}),
+	}
+}
+
+type evaluationBarRenderer struct {
+	evaluationBar *EvaluationBar
+
+	blackPart  *canvas.Rectangle
+	whitePart  *canvas.Rectangle
+	middleLine *canvas.Line
+}
+
+// Layout layouts the parts of the bar, the bar covering the height of the board cells.
+func (renderer *evaluationBarRenderer) Layout(size fyne.Size) {
+	cellsLength := size.Height / 9
+	barTop := cellsLength / 2
+	barHeight := 8 * cellsLength
+	whiteHeight := barHeight * renderer.evaluationBar.whiteShare
+
+	renderer.blackPart.Resize(fyne.NewSize(size.Width, barHeight))
+	renderer.blackPart.Move(fyne.NewPos(0, barTop))
+
+	renderer.whitePart.Resize(fyne.NewSize(size.Width, whiteHeight))
+	if renderer.evaluationBar.blackSide == BlackAtTop {
+		renderer.whitePart.Move(fyne.NewPos(0, barTop+barHeight-whiteHeight))
+	} else {
+		renderer.whitePart.Move(fyne.NewPos(0, barTop))
+	}
+
+	renderer.middleLine.StrokeWidth = 2
+	renderer.middleLine.Position1 = fyne.NewPos(0, barTop+barHeight/2)
+	renderer.middleLine.Position2 = fyne.NewPos(size.Width, barTop+barHeight/2)
+}
+
+// MinSize computes the minimum size.
+func (renderer *evaluationBarRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(renderer.evaluationBar.width, renderer.evaluationBar.length)
+}
+
+// Refresh refreshes the bar.
+func (renderer *evaluationBarRenderer) Refresh() {
+	renderer.Layout(renderer.evaluationBar.Size())
+	canvas.Refresh(renderer.evaluationBar)
+}
+
+// Objects returns the objects of the canvas of the renderer.
+func (renderer *evaluationBarRenderer) Objects() []fyne.CanvasObject {
+	return []fyne.CanvasObject{renderer.blackPart, renderer.whitePart, renderer.middleLine}
+}
+
+// Destroy cleans up the renderer.
+func (renderer *evaluationBarRenderer) Destroy() {
+
+}
@@ -0,0 +1,47 @@
+package chessboard
+
+import (
+	"fmt"
+	"sync"
+
+	"fyne.io/fyne/v2"
+)
+
+// eventQueue is implemented by the windows of the desktop driver, which
+// handle the user interaction events one after the other, on a single
+// goroutine. Fyne has no public way to run a function there yet.
+type eventQueue interface {
+	QueueEvent(event func())
+}
+
+// inlineEventsWarning reports once that the events are not queued.
+var inlineEventsWarning sync.Once
+
+// QueueEvent runs the given function with the user interaction events of the
+// given window, so that a timer can change the game without racing with the
+// moves of the user.
+//
+// Only the windows of the desktop driver have such a queue. With another
+// driver, such as the test one, or without window, the function is run right
+// away on the calling goroutine, which is reported once.
+func QueueEvent(window fyne.Window, event func()) {
+	if queue, ok := window.(eventQueue); ok {
+		queue.QueueEvent(event)
+		return
+	}
+
+	inlineEventsWarning.Do(func() {
+		fmt.Println("The window has no events queue: the timed events are run on their own goroutine.")
+	})
+	event()
+}
+
+// queueEvent runs the given function with the user interaction events of the
+// window of the board.
+func (board *ChessBoard) queueEvent(event func()) {
+	var window fyne.Window
+	if board.parent != nil {
+		window = *board.parent
+	}
+	QueueEvent(window, event)
+}
@@ -0,0 +1,78 @@
+package chessboard
+
+import (
+	"math"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/loloof64/chess-pgn-reviser-fyne/commonTypes"
+)
+
+// boardGeometry maps the cells of the board to the points of the widget.
+// The board keeps a square shape, centered in the widget, and its cells are
+// surrounded by a border half a cell wide, holding the coordinates.
+type boardGeometry struct {
+	// origin is the top left corner of the board, border included.
+	origin fyne.Position
+
+	cellsLength float32
+	blackSide   BlackSide
+}
+
+// geometry returns the geometry of the board, for a widget of the given size.
+func (board *ChessBoard) geometry(size fyne.Size) boardGeometry {
+	minSize := math.Min(float64(size.Width), float64(size.Height))
+	// Whole cells lengths avoid gaps between the cells.
+	cellsLength := float32(math.Floor(minSize / 9.0))
+
+	return boardGeometry{
+		origin:      fyne.NewPos((size.Width-9*cellsLength)/2, (size.Height-9*cellsLength)/2),
+		cellsLength: cellsLength,
+		blackSide:   board.blackSide,
+	}
+}
+
+// boardSize returns the size of the board, border included.
+func (geometry boardGeometry) boardSize() fyne.Size {
+	return fyne.NewSize(9*geometry.cellsLength, 9*geometry.cellsLength)
+}
+
+// cellsSize returns the size of a cell.
+func (geometry boardGeometry) cellsSize() fyne.Size {
+	return fyne.NewSize(geometry.cellsLength, geometry.cellsLength)
+}
+
+// pointAt returns the point at the given distances from the board origin,
+// given in cells lengths.
+func (geometry boardGeometry) pointAt(x float32, y float32) fyne.Position {
+	return fyne.NewPos(geometry.origin.X+x*geometry.cellsLength, geometry.origin.Y+y*geometry.cellsLength)
+}
+
+// cellAt returns the cell under the given point, and whether the point is inside the cells.
+func (geometry boardGeometry) cellAt(position fyne.Position) (commonTypes.Cell, bool) {
+	column := int(math.Floor(float64((position.X-geometry.origin.X)/geometry.cellsLength - 0.5)))
+	line := int(math.Floor(float64((position.Y-geometry.origin.Y)/geometry.cellsLength - 0.5)))
+	if column < 0 || column > 7 || line < 0 || line > 7 {
+		return commonTypes.Cell{}, false
+	}
+
+	if geometry.blackSide == BlackAtTop {
+		return commonTypes.Cell{File: int8(column), Rank: int8(7 - line)}, true
+	}
+	return commonTypes.Cell{File: int8(7 - column), Rank: int8(line)}, true
+}
+
+// cellPosition returns the top left corner of the given cell.
+func (geometry boardGeometry) cellPosition(cell commonTypes.Cell) fyne.Position {
+	column, line := float32(cell.File), float32(7-cell.Rank)
+	if geometry.blackSide == BlackAtBottom {
+		column, line = float32(7-cell.File), float32(cell.Rank)
+	}
+	return geometry.pointAt(0.5+column, 0.5+line)
+}
+
+// cellCenter returns the center of the given cell.
+func (geometry boardGeometry) cellCenter(cell commonTypes.Cell) fyne.Position {
+	half := geometry.cellsLength / 2
+	return geometry.cellPosition(cell).Add(fyne.NewPos(half, half))
+}
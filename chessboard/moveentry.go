@@ -0,0 +1,144 @@
+package chessboard
+
+import (
+	"strings"
+	"unicode"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/gookit/ini/v2"
+	"github.com/notnil/chess"
+
+	"github.com/loloof64/chess-pgn-reviser-fyne/commonTypes"
+)
+
+// MoveEntry lets the user type the moves to play on a ChessBoard, in Standard
+// Algebraic Notation or in UCI notation, listing the matching legal moves while typing.
+type MoveEntry struct {
+	widget.BaseWidget
+
+	board      *ChessBoard
+	entry      *widget.SelectEntry
+	candidates *widget.Label
+}
+
+// NewMoveEntry creates a move entry for the given board.
+func NewMoveEntry(board *ChessBoard) *MoveEntry {
+	moveEntry := &MoveEntry{board: board}
+	moveEntry.ExtendBaseWidget(moveEntry)
+
+	moveEntry.candidates = widget.NewLabel("")
+	moveEntry.candidates.Wrapping = fyne.TextWrapWord
+
+	moveEntry.entry = widget.NewSelectEntry(nil)
+	moveEntry.entry.SetPlaceHolder(ini.String("moveEntry.placeholder"))
+	moveEntry.entry.OnChanged = moveEntry.updateCandidates
+	moveEntry.entry.OnSubmitted = moveEntry.submit
+
+	return moveEntry
+}
+
+// CreateRenderer creates the renderer of the move entry.
+func (moveEntry *MoveEntry) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(container.NewVBox(moveEntry.entry, moveEntry.candidates))
+}
+
+func (moveEntry *MoveEntry) updateCandidates(input string) {
+	candidates := moveEntry.board.MoveCandidates(input)
+	moveEntry.entry.SetOptions(candidates)
+	if strings.TrimSpace(input) == "" {
+		moveEntry.candidates.SetText("")
+	} else {
+		moveEntry.candidates.SetText(strings.Join(candidates, " "))
+	}
+}
+
+func (moveEntry *MoveEntry) submit(input string) {
+	if moveEntry.board.PlayTypedMove(input) {
+		moveEntry.entry.SetText("")
+		moveEntry.updateCandidates("")
+	}
+}
+
+// MoveCandidates returns, in Standard Algebraic Notation, the legal moves of the
+// user starting like the given input, in Standard Algebraic Notation or in UCI notation.
+func (board *ChessBoard) MoveCandidates(input string) []string {
+	candidates := []string{}
+	if !board.gameInProgress {
+		return candidates
+	}
+
+	sanInput := normalizeTypedMove(input)
+	uciInput := strings.ToLower(strings.TrimSpace(input))
+	position := board.game.Position()
+	for _, currentMove := range board.game.ValidMoves() {
+		if !board.userCanMove(position.Board().Piece(currentMove.S1())) {
+			continue
+		}
+
+		san := chess.AlgebraicNotation{}.Encode(position, currentMove)
+		matches := strings.HasPrefix(san, sanInput) || strings.HasPrefix(currentMove.String(), uciInput)
+		if matches {
+			candidates = append(candidates, san)
+		}
+	}
+	return candidates
+}
+
+// PlayTypedMove plays the user move given in Standard Algebraic Notation or
+// in UCI notation, as if it had been dragged. Returns whether the move has been played.
+func (board *ChessBoard) PlayTypedMove(input string) bool {
+	if !board.gameInProgress || board.pendingPromotion || board.dragndropInProgress {
+		return false
+	}
+
+	position := board.game.Position()
+	typedMoves := []struct {
+		decoder chess.Decoder
+		input   string
+	}{
+		{decoder: chess.AlgebraicNotation{}, input: normalizeTypedMove(input)},
+		{decoder: chess.UCINotation{}, input: strings.ToLower(strings.TrimSpace(input))},
+	}
+
+	for _, typedMove := range typedMoves {
+		decodedMove, err := typedMove.decoder.Decode(position, typedMove.input)
+		if err != nil {
+			continue
+		}
+		for _, currentMove := range board.game.ValidMoves() {
+			isTypedMove := currentMove.String() == decodedMove.String() &&
+				board.userCanMove(position.Board().Piece(currentMove.S1()))
+			if isTypedMove {
+				board.selectedCell = nil
+				return board.commitUserMove(currentMove)
+			}
+		}
+	}
+
+	return false
+}
+
+// normalizeTypedMove removes the spaces from a move typed in Standard
+// Algebraic Notation, and gives it the case of the notation, whatever the
+// case typed: the pieces in uppercase, and the files in lowercase. A leading
+// b is the only exception, being the file of a pawn move, while a leading B
+// is a bishop move. The zeros sometimes used for castling are also replaced.
+func normalizeTypedMove(input string) string {
+	input = strings.TrimSpace(input)
+	if upperInput := strings.ToUpper(input); strings.HasPrefix(upperInput, "O-O") || strings.HasPrefix(upperInput, "0-0") {
+		return commonTypes.NormalizeCastling(upperInput)
+	}
+
+	normalized := []rune(strings.ToLower(input))
+	for index, char := range normalized {
+		isPiece := index == 0 && strings.ContainsRune("nrqk", char) ||
+			index > 0 && normalized[index-1] == '='
+		if isPiece || index == 0 && input[0] == 'B' {
+			normalized[index] = unicode.ToUpper(char)
+		}
+	}
+	return string(normalized)
+}
@@ -0,0 +1,30 @@
+package chessboard
+
+import "testing"
+
+func TestNormalizeTypedMove(t *testing.T) {
+	tests := []struct {
+		input      string
+		normalized string
+	}{
+		{input: " Nf3 ", normalized: "Nf3"},
+		{input: "nf3", normalized: "Nf3"},
+		{input: "NF3", normalized: "Nf3"},
+		{input: "E4", normalized: "e4"},
+		{input: "rxA8+", normalized: "Rxa8+"},
+		{input: "Bb5", normalized: "Bb5"},
+		{input: "bxc6", normalized: "bxc6"},
+		{input: "BXC6", normalized: "Bxc6"},
+		{input: "nBD2", normalized: "Nbd2"},
+		{input: "e8=q", normalized: "e8=Q"},
+		{input: "exd8=n#", normalized: "exd8=N#"},
+		{input: "0-0", normalized: "O-O"},
+		{input: "o-o-o+", normalized: "O-O-O+"},
+	}
+
+	for _, test := range tests {
+		if normalized := normalizeTypedMove(test.input); normalized != test.normalized {
+			t.Errorf("%q: got %q, expected %q", test.input, normalized, test.normalized)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package chessboard
+
+import (
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+
+	"github.com/loloof64/chess-pgn-reviser-fyne/commonTypes"
+)
+
+// Arrow is an arrow painted over the board, from the center of a cell to the center of another one.
+type Arrow struct {
+	Origin commonTypes.Cell
+	Target commonTypes.Cell
+	Color  color.Color
+}
+
+// Highlight is a color painted over a cell of the board. A translucent color
+// lets the cell and its piece show through.
+type Highlight struct {
+	Cell  commonTypes.Cell
+	Color color.Color
+}
+
+// Circle is a ring painted around a cell of the board.
+type Circle struct {
+	Cell  commonTypes.Cell
+	Color color.Color
+}
+
+// Overlay is a set of arrows, highlights and circles painted over the board.
+type Overlay struct {
+	Arrows     []Arrow
+	Highlights []Highlight
+	Circles    []Circle
+}
+
+// overlayLayer is a named overlay, with the canvas objects painting it.
+type overlayLayer struct {
+	name    string
+	overlay Overlay
+
+	arrowsLines       []fyne.CanvasObject
+	highlightsObjects []fyne.CanvasObject
+	circlesObjects    []fyne.CanvasObject
+}
+
+// SetOverlay paints the given overlay over the board, replacing the overlay
+// with the same name if any. Overlays are painted in the order they have
+// first been set, so that the layers of the analysis, the hints and the
+// annotations don't erase each other.
+func (board *ChessBoard) SetOverlay(name string, overlay Overlay) {
+	layer := board.overlayLayer(name)
+	if layer == nil {
+		layer = &overlayLayer{name: name}
+		board.overlays = append(board.overlays, layer)
+	}
+	layer.overlay = overlay
+	board.Refresh()
+}
+
+// ClearOverlay removes the overlay with the given name, if any.
+func (board *ChessBoard) ClearOverlay(name string) {
+	for index, layer := range board.overlays {
+		if layer.name == name {
+			board.overlays = append(board.overlays[:index], board.overlays[index+1:]...)
+			board.Refresh()
+			return
+		}
+	}
+}
+
+// Overlay returns the overlay with the given name, and whether it is set.
+func (board *ChessBoard) Overlay(name string) (Overlay, bool) {
+	layer := board.overlayLayer(name)
+	if layer == nil {
+		return Overlay{}, false
+	}
+	return layer.overlay, true
+}
+
+func (board *ChessBoard) overlayLayer(name string) *overlayLayer {
+	for _, layer := range board.overlays {
+		if layer.name == name {
+			return layer
+		}
+	}
+	return nil
+}
+
+// layoutOverlays builds the canvas objects of the overlays, for a board of the given size.
+func (board *ChessBoard) layoutOverlays(size fyne.Size) {
+	geometry := board.geometry(size)
+	cellsSize := geometry.cellsSize()
+
+	for _, layer := range board.overlays {
+		layer.highlightsObjects = make([]fyne.CanvasObject, 0, len(layer.overlay.Highlights))
+		for _, highlight := range layer.overlay.Highlights {
+			rectangle := canvas.NewRectangle(highlight.Color)
+			rectangle.Resize(cellsSize)
+			rectangle.Move(geometry.cellPosition(highlight.Cell))
+			layer.highlightsObjects = append(layer.highlightsObjects, rectangle)
+		}
+
+		layer.circlesObjects = make([]fyne.CanvasObject, 0, len(layer.overlay.Circles))
+		for _, circle := range layer.overlay.Circles {
+			ring := canvas.NewCircle(color.Transparent)
+			ring.StrokeColor = circle.Color
+			ring.StrokeWidth = geometry.cellsLength * float32(0.08)
+			ring.Resize(cellsSize)
+			ring.Move(geometry.cellPosition(circle.Cell))
+			layer.circlesObjects = append(layer.circlesObjects, ring)
+		}
+
+		layer.arrowsLines = make([]fyne.CanvasObject, 0, 3*len(layer.overlay.Arrows))
+		for _, arrow := range layer.overlay.Arrows {
+			// An arrow needs a direction.
+			if arrow.Origin == arrow.Target {
+				continue
+			}
+			lines := board.makeCellsArrow(size, arrow.Origin, arrow.Target, arrow.Color)
+			layer.arrowsLines = append(layer.arrowsLines, lines...)
+		}
+	}
+}
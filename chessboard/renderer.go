@@ -2,11 +2,12 @@ package chessboard
 
 import (
 	"image/color"
-	"math"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
 	"github.com/notnil/chess"
+
+	"github.com/loloof64/chess-pgn-reviser-fyne/commonTypes"
 )
 
 // Renderer renders a ChessBoard.
@@ -24,6 +25,7 @@ type Renderer struct {
 func (renderer Renderer) Layout(size fyne.Size) {
 	renderer.layoutCells(size)
 	renderer.boardWidget.LayoutLastMoveArrowIfNeeded(size)
+	renderer.boardWidget.layoutOverlays(size)
 	renderer.layoutPieces(size)
 	renderer.layoutMovedPieceIfAny(size)
 	renderer.layoutFilesCoordinates(size)
@@ -31,6 +33,7 @@ func (renderer Renderer) Layout(size fyne.Size) {
 	renderer.layoutPlayerTurn(size)
 
 	renderer.updatePlayerTurn()
+	renderer.updateThemeColors()
 	renderer.updateCellsForDragAndDrop()
 }
 
@@ -50,7 +53,6 @@ func (renderer Renderer) Refresh() {
 func (renderer Renderer) Objects() []fyne.CanvasObject {
 	result := make([]fyne.CanvasObject, 0, 170)
 
-	renderer.background.Resize(renderer.MinSize())
 	result = append(result, renderer.background)
 
 	for rank := 0; rank < 8; rank++ {
@@ -62,6 +64,10 @@ func (renderer Renderer) Objects() []fyne.CanvasObject {
 		}
 	}
 
+	for _, layer := range renderer.boardWidget.overlays {
+		result = append(result, layer.highlightsObjects...)
+	}
+
 	for rank := 0; rank < 8; rank++ {
 		for file := 0; file < 8; file++ {
 			piece := renderer.boardWidget.pieces[rank][file]
@@ -94,9 +100,12 @@ func (renderer Renderer) Objects() []fyne.CanvasObject {
 	}
 
 	if renderer.boardWidget.lastMove != nil {
-		result = append(result, &renderer.boardWidget.lastMove.baseline)
-		result = append(result, &renderer.boardWidget.lastMove.leftArrowLine)
-		result = append(result, &renderer.boardWidget.lastMove.rightArrowLine)
+		result = append(result, renderer.boardWidget.lastMove.arrowLines...)
+	}
+
+	for _, layer := range renderer.boardWidget.overlays {
+		result = append(result, layer.circlesObjects...)
+		result = append(result, layer.arrowsLines...)
 	}
 
 	return result
@@ -108,126 +117,86 @@ func (renderer Renderer) Destroy() {
 }
 
 func (renderer Renderer) layoutCells(size fyne.Size) {
-	minSize := math.Min(float64(size.Width), float64(size.Height))
-	cellsLength := int(minSize / 9.0)
-	halfCellsLength := int(cellsLength / 2)
-	cellsSize := fyne.Size{Width: float32(cellsLength), Height: float32(cellsLength)}
+	geometry := renderer.boardWidget.geometry(size)
+	renderer.background.Resize(geometry.boardSize())
+	renderer.background.Move(geometry.origin)
 
 	for lineIndex, lineValues := range renderer.cells {
 		for colIndex, cellValue := range lineValues {
-			var x, y float32
-			if renderer.boardWidget.blackSide == BlackAtTop {
-				x = float32(halfCellsLength + colIndex*cellsLength)
-				y = float32(halfCellsLength + (7-lineIndex)*cellsLength)
-			} else {
-				x = float32(halfCellsLength + (7-colIndex)*cellsLength)
-				y = float32(halfCellsLength + lineIndex*cellsLength)
-			}
-			cellPosition := fyne.Position{X: x, Y: y}
-
-			cellValue.Resize(cellsSize)
-			cellValue.Move(cellPosition)
+			cell := commonTypes.Cell{File: int8(colIndex), Rank: int8(lineIndex)}
+			cellValue.Resize(geometry.cellsSize())
+			cellValue.Move(geometry.cellPosition(cell))
 		}
 	}
 }
 
 func (renderer Renderer) layoutPieces(size fyne.Size) {
-	minSize := math.Min(float64(size.Width), float64(size.Height))
-	cellsLength := int(minSize / 9.0)
-	halfCellsLength := int(cellsLength / 2)
-	cellsSize := fyne.Size{Width: float32(cellsLength), Height: float32(cellsLength)}
+	geometry := renderer.boardWidget.geometry(size)
 
 	for lineIndex, lineValues := range renderer.cells {
 		for colIndex := range lineValues {
-			var x, y float32
-			if renderer.boardWidget.blackSide == BlackAtTop {
-				x = float32(halfCellsLength + colIndex*cellsLength)
-				y = float32(halfCellsLength + (7-lineIndex)*cellsLength)
-			} else {
-				x = float32(halfCellsLength + (7-colIndex)*cellsLength)
-				y = float32(halfCellsLength + lineIndex*cellsLength)
-			}
-			cellPosition := fyne.Position{X: x, Y: y}
-
 			currentPiece := renderer.boardWidget.pieces[lineIndex][colIndex]
 
 			if currentPiece != nil {
-				currentPiece.Resize(cellsSize)
-				currentPiece.Move(cellPosition)
+				cell := commonTypes.Cell{File: int8(colIndex), Rank: int8(lineIndex)}
+				currentPiece.Resize(geometry.cellsSize())
+				currentPiece.Move(geometry.cellPosition(cell))
 			}
 		}
 	}
 }
 
 func (renderer Renderer) layoutFilesCoordinates(size fyne.Size) {
-	minSize := math.Min(float64(size.Width), float64(size.Height))
-	cellsLength := float64(minSize / 9.0)
-
-	coordsFontSize := cellsLength * float64(0.35)
-
-	fileCoordsOffset := cellsLength * float64(0.95)
+	geometry := renderer.boardWidget.geometry(size)
+	coordsFontSize := geometry.cellsLength * float32(0.35)
 
 	for file := 0; file < 8; file++ {
-		var x float32
-		if renderer.boardWidget.blackSide == BlackAtTop {
-			x = float32(fileCoordsOffset + cellsLength*float64(file))
-		} else {
-			x = float32(fileCoordsOffset + cellsLength*float64(7-file))
+		column := float32(file)
+		if renderer.boardWidget.blackSide == BlackAtBottom {
+			column = float32(7 - file)
 		}
-		yTop := float32(cellsLength * float64(0.015))
-		yBottom := float32(cellsLength * float64(8.515))
 
 		topCoord := renderer.filesCoords[0][file]
 		topCoord.TextStyle = fyne.TextStyle{Bold: true}
-		topCoord.TextSize = float32(coordsFontSize)
-		topCoord.Move(fyne.Position{X: x, Y: yTop})
+		topCoord.TextSize = coordsFontSize
+		topCoord.Move(geometry.pointAt(0.95+column, 0.015))
 
 		bottomCoord := renderer.filesCoords[1][file]
 		bottomCoord.TextStyle = fyne.TextStyle{Bold: true}
-		bottomCoord.TextSize = float32(coordsFontSize)
-		bottomCoord.Move(fyne.Position{X: x, Y: yBottom})
+		bottomCoord.TextSize = coordsFontSize
+		bottomCoord.Move(geometry.pointAt(0.95+column, 8.515))
 	}
 }
 
 func (renderer Renderer) layoutRanksCoordinates(size fyne.Size) {
-	minSize := math.Min(float64(size.Width), float64(size.Height))
-	cellsLength := float64(minSize / 9.0)
-
-	coordsFontSize := cellsLength * float64(0.35)
-
-	rankCoordsOffset := cellsLength * float64(0.8)
+	geometry := renderer.boardWidget.geometry(size)
+	coordsFontSize := geometry.cellsLength * float32(0.35)
 
 	for rank := 0; rank < 8; rank++ {
-		var y float32
-		if renderer.boardWidget.blackSide == BlackAtTop {
-			y = float32(rankCoordsOffset + cellsLength*float64(rank))
-		} else {
-			y = float32(rankCoordsOffset + cellsLength*float64(7-rank))
+		line := float32(rank)
+		if renderer.boardWidget.blackSide == BlackAtBottom {
+			line = float32(7 - rank)
 		}
-		xLeft := float32(cellsLength * float64(0.2))
-		xRight := float32(cellsLength * float64(8.7))
 
 		leftCoord := renderer.ranksCoords[0][rank]
 		leftCoord.TextStyle = fyne.TextStyle{Bold: true}
-		leftCoord.TextSize = float32(coordsFontSize)
-		leftCoord.Move(fyne.Position{X: xLeft, Y: y})
+		leftCoord.TextSize = coordsFontSize
+		leftCoord.Move(geometry.pointAt(0.2, 0.8+line))
 
 		rightCoord := renderer.ranksCoords[1][rank]
 		rightCoord.TextStyle = fyne.TextStyle{Bold: true}
-		rightCoord.TextSize = float32(coordsFontSize)
-		rightCoord.Move(fyne.Position{X: xRight, Y: y})
+		rightCoord.TextSize = coordsFontSize
+		rightCoord.Move(geometry.pointAt(8.7, 0.8+line))
 	}
 }
 
 func (renderer Renderer) layoutPlayerTurn(size fyne.Size) {
-	minSize := math.Min(float64(size.Width), float64(size.Height))
-	cellsLength := int(minSize / 9.0)
-	halfCellsLength := cellsLength / 2
+	geometry := renderer.boardWidget.geometry(size)
+	halfCellsLength := geometry.cellsLength / 2
 
-	turnCirclePlace := float32(cellsLength) * float32(8.5)
 	turnCircle := renderer.playerTurn
-	turnCircle.Resize(fyne.Size{Width: float32(halfCellsLength), Height: float32(halfCellsLength)})
-	turnCircle.Move(fyne.Position{X: turnCirclePlace, Y: turnCirclePlace})
+	turnCircle.Resize(fyne.Size{Width: halfCellsLength, Height: halfCellsLength})
+	turnCircle.Move(geometry.pointAt(8.5, 8.5))
 }
 
 func (renderer Renderer) updatePlayerTurn() {
@@ -240,9 +209,7 @@ func (renderer Renderer) updatePlayerTurn() {
 }
 
 func (renderer Renderer) layoutMovedPieceIfAny(size fyne.Size) {
-	minSize := math.Min(float64(size.Width), float64(size.Height))
-	cellsLength := float32(minSize / 9.0)
-	cellsSize := fyne.Size{Width: cellsLength, Height: cellsLength}
+	cellsSize := renderer.boardWidget.geometry(size).cellsSize()
 
 	if renderer.boardWidget.dragndropInProgress {
 		renderer.boardWidget.movedPiece.pieceImage.Resize(cellsSize)
@@ -250,9 +217,20 @@ func (renderer Renderer) layoutMovedPieceIfAny(size fyne.Size) {
 	}
 }
 
+func (renderer Renderer) updateThemeColors() {
+	colors := renderer.boardWidget.theme.Colors
+	renderer.background.FillColor = colors.Border
+	for side := 0; side < 2; side++ {
+		for index := 0; index < 8; index++ {
+			renderer.filesCoords[side][index].Color = colors.Coordinates
+			renderer.ranksCoords[side][index].Color = colors.Coordinates
+		}
+	}
+}
+
 func (renderer Renderer) updateCellsForDragAndDrop() {
-	whiteCellColor := color.RGBA{255, 206, 158, 0xff}
-	blackCellColor := color.RGBA{209, 139, 71, 0xff}
+	whiteCellColor := renderer.boardWidget.theme.Colors.WhiteCells
+	blackCellColor := renderer.boardWidget.theme.Colors.BlackCells
 
 	dndCrossCellColor := color.RGBA{255, 20, 200, 0xff}
 	dndOriginCellColor := color.RGBA{255, 20, 30, 0xff}
@@ -267,6 +245,16 @@ func (renderer Renderer) updateCellsForDragAndDrop() {
 				renderer.cells[rank][file].FillColor = blackCellColor
 			}
 
+			selectedCell := renderer.boardWidget.selectedCell
+			if selectedCell != nil {
+				cell := commonTypes.Cell{File: int8(file), Rank: int8(rank)}
+				if cell == *selectedCell {
+					renderer.cells[rank][file].FillColor = dndOriginCellColor
+				} else if renderer.boardWidget.isLegalTarget(*selectedCell, cell) {
+					renderer.cells[rank][file].FillColor = dndTargetCellColor
+				}
+			}
+
 			if renderer.boardWidget.dragndropInProgress == false {
 				continue
 			}
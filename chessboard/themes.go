@@ -0,0 +1,180 @@
+package chessboard
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"github.com/notnil/chess"
+)
+
+// ColorScheme gives the colors of the board.
+type ColorScheme struct {
+	// Name identifies the scheme, such as classic.
+	Name string
+
+	WhiteCells  color.Color
+	BlackCells  color.Color
+	Border      color.Color
+	Coordinates color.Color
+}
+
+// ColorSchemes are the built-in color schemes, the default one first.
+var ColorSchemes = []ColorScheme{
+	{
+		Name:        "classic",
+		WhiteCells:  color.RGBA{255, 206, 158, 0xff},
+		BlackCells:  color.RGBA{209, 139, 71, 0xff},
+		Border:      color.NRGBA{R: 20, G: 110, B: 200, A: 0xff},
+		Coordinates: color.RGBA{255, 199, 0, 0xff},
+	},
+	{
+		Name:        "green",
+		WhiteCells:  color.RGBA{238, 238, 210, 0xff},
+		BlackCells:  color.RGBA{118, 150, 86, 0xff},
+		Border:      color.RGBA{49, 46, 43, 0xff},
+		Coordinates: color.RGBA{238, 238, 210, 0xff},
+	},
+	{
+		Name:        "blue",
+		WhiteCells:  color.RGBA{222, 227, 230, 0xff},
+		BlackCells:  color.RGBA{140, 162, 173, 0xff},
+		Border:      color.RGBA{38, 58, 82, 0xff},
+		Coordinates: color.RGBA{222, 227, 230, 0xff},
+	},
+	{
+		Name:        "gray",
+		WhiteCells:  color.RGBA{220, 220, 220, 0xff},
+		BlackCells:  color.RGBA{150, 150, 150, 0xff},
+		Border:      color.RGBA{60, 60, 60, 0xff},
+		Coordinates: color.RGBA{255, 255, 255, 0xff},
+	},
+}
+
+// ColorSchemeByName returns the built-in color scheme with the given name,
+// or the default one if there is none.
+func ColorSchemeByName(name string) ColorScheme {
+	for _, scheme := range ColorSchemes {
+		if scheme.Name == name {
+			return scheme
+		}
+	}
+	return ColorSchemes[0]
+}
+
+// PieceSet gives the pictures of the pieces.
+type PieceSet struct {
+	// Name identifies the set, such as standard.
+	Name string
+
+	pictures map[chess.Piece]fyne.Resource
+}
+
+// Picture returns the picture of the given piece.
+func (set *PieceSet) Picture(piece chess.Piece) fyne.Resource {
+	return set.pictures[piece]
+}
+
+// standardPieceSet holds the bundled pictures, from which the other built-in sets are painted.
+var standardPieceSet = &PieceSet{
+	Name: "standard",
+	pictures: map[chess.Piece]fyne.Resource{
+		chess.WhitePawn:   resourceChessplt45Svg,
+		chess.WhiteKnight: resourceChessnlt45Svg,
+		chess.WhiteBishop: resourceChessblt45Svg,
+		chess.WhiteRook:   resourceChessrlt45Svg,
+		chess.WhiteQueen:  resourceChessqlt45Svg,
+		chess.WhiteKing:   resourceChessklt45Svg,
+		chess.BlackPawn:   resourceChesspdt45Svg,
+		chess.BlackKnight: resourceChessndt45Svg,
+		chess.BlackBishop: resourceChessbdt45Svg,
+		chess.BlackRook:   resourceChessrdt45Svg,
+		chess.BlackQueen:  resourceChessqdt45Svg,
+		chess.BlackKing:   resourceChesskdt45Svg,
+	},
+}
+
+// PieceSets are the built-in piece sets, the default one first.
+var PieceSets = []*PieceSet{
+	standardPieceSet,
+	repaintPieceSet("wood", "#f3dfb8", "#5c3517"),
+	repaintPieceSet("ocean", "#e8f1fa", "#1d3d63"),
+}
+
+// repaintPieceSet paints the standard pieces again, replacing their white and black.
+func repaintPieceSet(name string, light string, dark string) *PieceSet {
+	set := &PieceSet{Name: name, pictures: map[chess.Piece]fyne.Resource{}}
+	for piece, picture := range standardPieceSet.pictures {
+		content := bytes.ReplaceAll(picture.Content(), []byte("#ffffff"), []byte(light))
+		content = bytes.ReplaceAll(content, []byte("#000000"), []byte(dark))
+		set.pictures[piece] = fyne.NewStaticResource(name+"_"+picture.Name(), content)
+	}
+	return set
+}
+
+// PieceSetByName returns the built-in piece set with the given name, or the
+// default one if there is none.
+func PieceSetByName(name string) *PieceSet {
+	for _, set := range PieceSets {
+		if set.Name == name {
+			return set
+		}
+	}
+	return PieceSets[0]
+}
+
+// pieceFileNames gives the names of the pictures of a user piece set,
+// without their extension, as wK for the white king or bP for a black pawn.
+var pieceFileNames = map[chess.Piece]string{
+	chess.WhitePawn:   "wP",
+	chess.WhiteKnight: "wN",
+	chess.WhiteBishop: "wB",
+	chess.WhiteRook:   "wR",
+	chess.WhiteQueen:  "wQ",
+	chess.WhiteKing:   "wK",
+	chess.BlackPawn:   "bP",
+	chess.BlackKnight: "bN",
+	chess.BlackBishop: "bB",
+	chess.BlackRook:   "bR",
+	chess.BlackQueen:  "bQ",
+	chess.BlackKing:   "bK",
+}
+
+// LoadPieceSet reads a piece set from a directory holding a picture of each
+// piece, as an SVG or a PNG file named after the piece: wK.svg for the white
+// king, bP.png for a black pawn, and so on.
+func LoadPieceSet(directory string) (*PieceSet, error) {
+	set := &PieceSet{Name: filepath.Base(directory), pictures: map[chess.Piece]fyne.Resource{}}
+	for piece, fileName := range pieceFileNames {
+		for _, extension := range []string{".svg", ".png"} {
+			content, err := ioutil.ReadFile(filepath.Join(directory, fileName+extension))
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			set.pictures[piece] = fyne.NewStaticResource(fileName+extension, content)
+			break
+		}
+		if set.pictures[piece] == nil {
+			return nil, fmt.Errorf("no picture %s.svg or %s.png in %s", fileName, fileName, directory)
+		}
+	}
+	return set, nil
+}
+
+// Theme gives the look of the board.
+type Theme struct {
+	Colors ColorScheme
+	Pieces *PieceSet
+}
+
+// DefaultTheme returns the theme of the board until another one is chosen.
+func DefaultTheme() Theme {
+	return Theme{Colors: ColorSchemes[0], Pieces: PieceSets[0]}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/gookit/ini/v2"
+
+	"github.com/loloof64/chess-pgn-reviser-fyne/chessboard"
+)
+
+// errInvalidClock is returned for clock times which are not positive whole numbers.
+var errInvalidClock = errors.New("invalid clock times")
+
+// newTimeControlChoice builds the widgets choosing the time control of a
+// revision, starting with the given one, and a function returning the chosen
+// time control, or errInvalidClock.
+func newTimeControlChoice(initial chessboard.TimeControl) (fyne.CanvasObject, func() (chessboard.TimeControl, error)) {
+	noClock := ini.String("sideSelection.noClock")
+	totalTime := ini.String("sideSelection.totalTime")
+	timePerMove := ini.String("sideSelection.timePerMove")
+
+	minutesEntry := widget.NewEntry()
+	minutesEntry.SetText(strconv.Itoa(int(initial.Total / time.Minute)))
+	incrementEntry := widget.NewEntry()
+	incrementEntry.SetText(strconv.Itoa(int(initial.Increment / time.Second)))
+	secondsPerMoveEntry := widget.NewEntry()
+	secondsPerMoveEntry.SetText(strconv.Itoa(int(initial.PerMove / time.Second)))
+
+	totalTimeForm := widget.NewForm(
+		widget.NewFormItem(ini.String("sideSelection.minutes"), minutesEntry),
+		widget.NewFormItem(ini.String("sideSelection.increment"), incrementEntry),
+	)
+	timePerMoveForm := widget.NewForm(
+		widget.NewFormItem(ini.String("sideSelection.secondsPerMove"), secondsPerMoveEntry),
+	)
+
+	modeChoice := widget.NewSelect([]string{noClock, totalTime, timePerMove}, func(mode string) {
+		totalTimeForm.Hide()
+		timePerMoveForm.Hide()
+		switch mode {
+		case totalTime:
+			totalTimeForm.Show()
+		case timePerMove:
+			timePerMoveForm.Show()
+		}
+	})
+	switch {
+	case initial.PerMove > 0:
+		modeChoice.SetSelected(timePerMove)
+	case initial.Total > 0:
+		modeChoice.SetSelected(totalTime)
+	default:
+		modeChoice.SetSelected(noClock)
+	}
+
+	content := container.NewVBox(
+		widget.NewForm(widget.NewFormItem(ini.String("sideSelection.clock"), modeChoice)),
+		totalTimeForm,
+		timePerMoveForm,
+	)
+
+	chosenTimeControl := func() (chessboard.TimeControl, error) {
+		switch modeChoice.Selected {
+		case totalTime:
+			minutes, err := parseClockTime(minutesEntry.Text)
+			if err != nil || minutes == 0 {
+				return chessboard.TimeControl{}, errInvalidClock
+			}
+			increment, err := parseClockTime(incrementEntry.Text)
+			if err != nil {
+				return chessboard.TimeControl{}, err
+			}
+			return chessboard.TimeControl{
+				Total:     time.Duration(minutes) * time.Minute,
+				Increment: time.Duration(increment) * time.Second,
+			}, nil
+		case timePerMove:
+			seconds, err := parseClockTime(secondsPerMoveEntry.Text)
+			if err != nil || seconds == 0 {
+				return chessboard.TimeControl{}, errInvalidClock
+			}
+			return chessboard.TimeControl{PerMove: time.Duration(seconds) * time.Second}, nil
+		}
+		return chessboard.TimeControl{}, nil
+	}
+
+	return content, chosenTimeControl
+}
+
+// parseClockTime reads a clock time typed by the user, which must be a whole
+// number, zero included.
+func parseClockTime(text string) (int, error) {
+	value, err := strconv.Atoi(text)
+	if err != nil || value < 0 {
+		return 0, errInvalidClock
+	}
+	return value, nil
+}
@@ -5,6 +5,9 @@ type GameMove struct {
 	// Fan is the move notation with figurines.
 	Fan string
 
+	// San is the move in Standard Algebraic Notation.
+	San string
+
 	// Fen is the position after the move, in Forsyth-Edwards Notation.
 	Fen string
 
@@ -20,6 +23,22 @@ type GameMove struct {
 
 	// IsBlackMove says whether it is a black move.
 	IsBlackMove bool
+
+	// Nags are the Numeric Annotation Glyphs of the move.
+	Nags []int
+
+	// Comments are the comments of the move, without their drawings commands.
+	Comments []string
+
+	// Drawings are the arrows and circled cells shown with the position after the move.
+	Drawings []Drawing
+
+	// Parent is the previous move, nil for a first move or for the root of a moves tree.
+	Parent *GameMove
+
+	// Children are the next moves: the first one continues the current line,
+	// the others start variations.
+	Children []*GameMove
 }
 
 // Cell defines a coordinate of a Chess Board widget.
@@ -0,0 +1,142 @@
+package commonTypes
+
+import (
+	"regexp"
+	"strings"
+)
+
+// DrawingColor is the color of a drawing, as written in the %cal and %csl
+// PGN comment commands.
+type DrawingColor byte
+
+const (
+	// GreenDrawing is the default drawing color.
+	GreenDrawing DrawingColor = 'G'
+
+	// RedDrawing is a red drawing.
+	RedDrawing DrawingColor = 'R'
+
+	// YellowDrawing is a yellow drawing.
+	YellowDrawing DrawingColor = 'Y'
+
+	// BlueDrawing is a blue drawing.
+	BlueDrawing DrawingColor = 'B'
+)
+
+// Drawing is an arrow drawn on the board, or a circled cell when its origin
+// and target cells are the same.
+type Drawing struct {
+	Origin Cell
+	Target Cell
+	Color  DrawingColor
+}
+
+// IsCircle says whether the drawing circles a cell, rather than being an arrow.
+func (drawing Drawing) IsCircle() bool {
+	return drawing.Origin == drawing.Target
+}
+
+// drawingsCommandPattern matches a %cal or %csl command, with its arguments.
+var drawingsCommandPattern = regexp.MustCompile(`\[%(cal|csl)\s+([^\]]*)\]`)
+
+// ParseDrawings reads the drawings of the %cal and %csl commands of a PGN
+// comment, ignoring the malformed ones.
+func ParseDrawings(comment string) []Drawing {
+	drawings := []Drawing{}
+	for _, command := range drawingsCommandPattern.FindAllStringSubmatch(comment, -1) {
+		isArrow := command[1] == "cal"
+		for _, argument := range strings.Split(command[2], ",") {
+			argument = strings.TrimSpace(argument)
+			if isArrow && len(argument) != 5 || !isArrow && len(argument) != 3 {
+				continue
+			}
+			drawingColor := DrawingColor(argument[0])
+			if !isDrawingColor(drawingColor) {
+				continue
+			}
+			origin, originValid := cellFromName(argument[1:3])
+			if !originValid {
+				continue
+			}
+			target := origin
+			if isArrow {
+				var targetValid bool
+				target, targetValid = cellFromName(argument[3:5])
+				if !targetValid {
+					continue
+				}
+			}
+			drawings = append(drawings, Drawing{Origin: origin, Target: target, Color: drawingColor})
+		}
+	}
+	return drawings
+}
+
+// RemoveDrawingsCommands returns the comment without its %cal and %csl
+// commands, its spaces being collapsed.
+func RemoveDrawingsCommands(comment string) string {
+	return strings.Join(strings.Fields(drawingsCommandPattern.ReplaceAllString(comment, " ")), " ")
+}
+
+// FormatDrawings writes the drawings as %csl and %cal commands, to be put in
+// a PGN comment. It returns an empty string if there is no drawing.
+func FormatDrawings(drawings []Drawing) string {
+	circles := []string{}
+	arrows := []string{}
+	for _, drawing := range drawings {
+		if drawing.IsCircle() {
+			circles = append(circles, string(drawing.Color)+drawing.Origin.Name())
+		} else {
+			arrows = append(arrows, string(drawing.Color)+drawing.Origin.Name()+drawing.Target.Name())
+		}
+	}
+
+	result := ""
+	if len(circles) > 0 {
+		result += "[%csl " + strings.Join(circles, ",") + "]"
+	}
+	if len(arrows) > 0 {
+		result += "[%cal " + strings.Join(arrows, ",") + "]"
+	}
+	return result
+}
+
+// ToggleDrawing adds the drawing to the given ones. A drawing between the
+// same cells is removed if it has the same color, and replaced otherwise.
+func ToggleDrawing(drawings []Drawing, drawing Drawing) []Drawing {
+	result := []Drawing{}
+	removed := false
+	for _, existing := range drawings {
+		if existing.Origin == drawing.Origin && existing.Target == drawing.Target {
+			removed = existing.Color == drawing.Color
+			continue
+		}
+		result = append(result, existing)
+	}
+	if !removed {
+		result = append(result, drawing)
+	}
+	return result
+}
+
+// Name returns the cell name in algebraic notation, such as e4.
+func (cell Cell) Name() string {
+	return string([]byte{byte('a' + cell.File), byte('1' + cell.Rank)})
+}
+
+func cellFromName(name string) (Cell, bool) {
+	file := int8(name[0]) - 'a'
+	rank := int8(name[1]) - '1'
+	if file < 0 || file > 7 || rank < 0 || rank > 7 {
+		return Cell{}, false
+	}
+	return Cell{File: file, Rank: rank}, true
+}
+
+func isDrawingColor(drawingColor DrawingColor) bool {
+	switch drawingColor {
+	case GreenDrawing, RedDrawing, YellowDrawing, BlueDrawing:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package commonTypes
+
+import (
+	"reflect"
+	"testing"
+)
+
+func arrow(color DrawingColor, origin string, target string) Drawing {
+	originCell, _ := cellFromName(origin)
+	targetCell, _ := cellFromName(target)
+	return Drawing{Origin: originCell, Target: targetCell, Color: color}
+}
+
+func circle(color DrawingColor, cell string) Drawing {
+	return arrow(color, cell, cell)
+}
+
+func TestParseDrawings(t *testing.T) {
+	tests := []struct {
+		name     string
+		comment  string
+		drawings []Drawing
+		text     string
+	}{
+		{
+			name:     "arrow",
+			comment:  "[%cal Ge2e4]",
+			drawings: []Drawing{arrow(GreenDrawing, "e2", "e4")},
+		},
+		{
+			name:    "several commands",
+			comment: "[%csl Rd5,Yh8][%cal Bb1c3, Ga1h8]",
+			drawings: []Drawing{circle(RedDrawing, "d5"), circle(YellowDrawing, "h8"),
+				arrow(BlueDrawing, "b1", "c3"), arrow(GreenDrawing, "a1", "h8")},
+		},
+		{
+			name:     "unknown colors",
+			comment:  "[%csl Xd5,gd6,Rd7][%cal Oe2e4]",
+			drawings: []Drawing{circle(RedDrawing, "d7")},
+		},
+		{
+			name:     "malformed cells",
+			comment:  "[%csl Ri9,Rz1,Rd][%cal Ge2e9,Ge2,Ge2e4e6,Gc2c4]",
+			drawings: []Drawing{arrow(GreenDrawing, "c2", "c4")},
+		},
+		{
+			name:     "unknown command",
+			comment:  "[%clk 0:05:00] [%cal Ge2e4]",
+			drawings: []Drawing{arrow(GreenDrawing, "e2", "e4")},
+			text:     "[%clk 0:05:00]",
+		},
+		{
+			name:     "mixed with text",
+			comment:  "Strong   center [%csl Ge4] with  [%cal Rd1h5] a threat",
+			drawings: []Drawing{circle(GreenDrawing, "e4"), arrow(RedDrawing, "d1", "h5")},
+			text:     "Strong center with a threat",
+		},
+		{
+			name:     "text only",
+			comment:  "No drawing [here]",
+			drawings: []Drawing{},
+			text:     "No drawing [here]",
+		},
+	}
+
+	for _, test := range tests {
+		if drawings := ParseDrawings(test.comment); !reflect.DeepEqual(drawings, test.drawings) {
+			t.Errorf("%s: got the drawings %v, expected %v", test.name, drawings, test.drawings)
+		}
+		if text := RemoveDrawingsCommands(test.comment); text != test.text {
+			t.Errorf("%s: got the text %q, expected %q", test.name, text, test.text)
+		}
+	}
+}
+
+func TestFormatDrawingsCanBeParsedBack(t *testing.T) {
+	tests := []string{
+		"",
+		"[%csl Gd4]",
+		"[%cal Re2e4]",
+		"[%csl Gd4,Yh1][%cal Re2e4,Ba7a1]",
+	}
+
+	for _, commands := range tests {
+		if formatted := FormatDrawings(ParseDrawings(commands)); formatted != commands {
+			t.Errorf("%q: got %q once parsed and formatted", commands, formatted)
+		}
+	}
+}
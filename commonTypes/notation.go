@@ -0,0 +1,43 @@
+package commonTypes
+
+import "strings"
+
+// ConvertSanToFan replaces the pieces letters of a move in Standard Algebraic Notation
+// with figurines.
+func ConvertSanToFan(san string, whiteMove bool) string {
+	fan := san
+	var kingChange, queenChange, rookChange, bishopChange, knightChange string
+	if whiteMove {
+		kingChange = "\u2654"
+		queenChange = "\u2655"
+		rookChange = "\u2656"
+		bishopChange = "\u2657"
+		knightChange = "\u2658"
+	} else {
+		kingChange = "\u265A"
+		queenChange = "\u265B"
+		rookChange = "\u265C"
+		bishopChange = "\u265D"
+		knightChange = "\u265E"
+	}
+
+	fan = strings.ReplaceAll(fan, "K", kingChange)
+	fan = strings.ReplaceAll(fan, "Q", queenChange)
+	fan = strings.ReplaceAll(fan, "R", rookChange)
+	fan = strings.ReplaceAll(fan, "B", bishopChange)
+	fan = strings.ReplaceAll(fan, "N", knightChange)
+
+	return fan
+}
+
+// NormalizeCastling replaces the zeros sometimes used for castling in a move
+// in Standard Algebraic Notation.
+func NormalizeCastling(san string) string {
+	if strings.HasPrefix(san, "0-0-0") {
+		return "O-O-O" + san[len("0-0-0"):]
+	}
+	if strings.HasPrefix(san, "0-0") {
+		return "O-O" + san[len("0-0"):]
+	}
+	return san
+}
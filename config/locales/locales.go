@@ -0,0 +1,102 @@
+// Package locales holds the translations of the interface, embedded in the binary.
+package locales
+
+import (
+	"embed"
+	"sort"
+
+	"github.com/cloudfoundry-attic/jibber_jabber"
+	"github.com/gookit/ini/v2"
+)
+
+// fallbackLanguage is the language of the translations missing from the other languages.
+const fallbackLanguage = "en"
+
+//go:embed *.ini
+var files embed.FS
+
+// Language is a language of the interface.
+type Language struct {
+	// Code names the locale file of the language, such as fr.
+	Code string
+
+	// Name is the name of the language, in the language itself.
+	Name string
+}
+
+// Languages are the languages of the interface.
+var Languages = []Language{
+	{Code: "en", Name: "English"},
+	{Code: "fr", Name: "Français"},
+	{Code: "es", Name: "Español"},
+}
+
+// Load replaces the translations by those of the given language, or of the
+// system language if it is empty. The keys missing from the language, and
+// the languages without locale file, are translated in English.
+func Load(language string) error {
+	if language == "" {
+		language = SystemLanguage()
+	}
+
+	ini.Reset()
+	err := loadFile(fallbackLanguage)
+	if err != nil {
+		return err
+	}
+	if language == fallbackLanguage || !isAvailable(language) {
+		return nil
+	}
+	return loadFile(language)
+}
+
+// SystemLanguage returns the code of the language of the system, or English
+// if it cannot be detected.
+func SystemLanguage() string {
+	language, err := jibber_jabber.DetectLanguage()
+	if err != nil {
+		return fallbackLanguage
+	}
+	return language
+}
+
+// Keys returns the keys of the locale file of the given language, as
+// section.key, sorted.
+func Keys(language string) ([]string, error) {
+	content, err := files.ReadFile(language + ".ini")
+	if err != nil {
+		return nil, err
+	}
+
+	localeData := ini.New()
+	err = localeData.LoadStrings(string(content))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{}
+	for sectionName, section := range localeData.Data() {
+		for key := range section {
+			keys = append(keys, sectionName+"."+key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func loadFile(language string) error {
+	content, err := files.ReadFile(language + ".ini")
+	if err != nil {
+		return err
+	}
+	return ini.LoadStrings(string(content))
+}
+
+func isAvailable(language string) bool {
+	file, err := files.Open(language + ".ini")
+	if err != nil {
+		return false
+	}
+	_ = file.Close()
+	return true
+}
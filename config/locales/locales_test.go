@@ -0,0 +1,61 @@
+package locales
+
+import (
+	"testing"
+
+	"github.com/gookit/ini/v2"
+)
+
+func TestLocaleFilesHaveTheSameKeys(t *testing.T) {
+	referenceKeys, err := Keys(fallbackLanguage)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, language := range Languages {
+		keys, err := Keys(language.Code)
+		if err != nil {
+			t.Fatalf("%s: %v", language.Code, err)
+		}
+		for _, missingKey := range difference(referenceKeys, keys) {
+			t.Errorf("%s misses the key %s", language.Code, missingKey)
+		}
+		for _, extraKey := range difference(keys, referenceKeys) {
+			t.Errorf("%s has the key %s, missing from %s", language.Code, extraKey, fallbackLanguage)
+		}
+	}
+}
+
+func TestLoadFallsBackToEnglishForUnknownLanguages(t *testing.T) {
+	err := Load("fr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cancel := ini.String("general.cancelButton"); cancel == "Cancel" {
+		t.Errorf("fr should be translated in French, got the cancel button %q", cancel)
+	}
+
+	err = Load("xx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cancel := ini.String("general.cancelButton"); cancel != "Cancel" {
+		t.Errorf("unknown languages should be translated in English, got the cancel button %q", cancel)
+	}
+}
+
+// difference returns the keys of first missing from second, both being sorted.
+func difference(first []string, second []string) []string {
+	secondKeys := map[string]bool{}
+	for _, key := range second {
+		secondKeys[key] = true
+	}
+
+	result := []string{}
+	for _, key := range first {
+		if !secondKeys[key] {
+			result = append(result, key)
+		}
+	}
+	return result
+}
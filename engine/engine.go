@@ -0,0 +1,436 @@
+package engine
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/notnil/chess"
+)
+
+// handshakeTimeout is the time given to the engine to answer the handshake commands.
+const handshakeTimeout = 10 * time.Second
+
+// stopTimeout is the time given to the engine to stop searching or to quit.
+const stopTimeout = 2 * time.Second
+
+// ErrTimeout is returned when the engine does not answer in time.
+var ErrTimeout = errors.New("engine: no answer in time")
+
+// MateCentipawns is the centipawns score of a mate in 0 moves, for the mating side.
+const MateCentipawns = 100000
+
+// ErrClosed is returned when the engine has been closed, or has exited.
+var ErrClosed = errors.New("engine: closed")
+
+// Info is an analysis report of the engine.
+type Info struct {
+	Depth int
+
+	// Score is the evaluation in centipawns, from the point of view of the side to move.
+	// It is meaningless when Mate is not 0.
+	Score int
+
+	// Mate is the count of moves before mate, negative when the side to move
+	// gets mated, or 0 when no mate has been found.
+	Mate int
+
+	// Pv is the best line found, in Standard Algebraic Notation.
+	Pv []string
+
+	// PvMoves is the best line found, as decoded moves.
+	PvMoves []*chess.Move
+}
+
+// Centipawns returns the score in centipawns, converting mates to big scores,
+// from the point of view of the side to move.
+func (info Info) Centipawns() int {
+	switch {
+	case info.Mate > 0:
+		return MateCentipawns - info.Mate
+	case info.Mate < 0:
+		return -MateCentipawns - info.Mate
+	default:
+		return info.Score
+	}
+}
+
+// WhiteCentipawns returns the score in centipawns, as Centipawns does, but
+// from the point of view of white.
+func (info Info) WhiteCentipawns(sideToMove chess.Color) int {
+	if sideToMove == chess.Black {
+		return -info.Centipawns()
+	}
+	return info.Centipawns()
+}
+
+// Engine talks with a UCI engine.
+type Engine struct {
+	// Name is the name given by the engine.
+	Name string
+
+	input   io.WriteCloser
+	process *exec.Cmd
+
+	// responses receives the lines of the engine, except the info lines.
+	responses chan string
+
+	// commandMutex serializes the commands, infoMutex guards the analysis handler.
+	commandMutex sync.Mutex
+	infoMutex    sync.Mutex
+
+	searching        bool
+	analyzedPosition *chess.Position
+	onInfo           func(info Info)
+}
+
+// Start launches the UCI engine at the given path, and does the handshake.
+func Start(path string) (*Engine, error) {
+	process := exec.Command(path)
+	input, err := process.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	output, err := process.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	err = process.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	engine, err := Connect(output, input)
+	if err != nil {
+		_ = process.Process.Kill()
+		_ = process.Wait()
+		return nil, err
+	}
+	engine.process = process
+
+	return engine, nil
+}
+
+// Connect does the handshake with a UCI engine reading its commands from input,
+// and writing its answers to output. Start uses it for engine processes, and it
+// can be given a fake engine in tests.
+func Connect(output io.Reader, input io.WriteCloser) (*Engine, error) {
+	engine := &Engine{input: input, responses: make(chan string, 100)}
+	go engine.readOutput(output)
+
+	err := engine.send("uci")
+	if err != nil {
+		return nil, err
+	}
+	for {
+		response, err := engine.waitFor("", handshakeTimeout)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(response, "id name ") {
+			engine.Name = strings.TrimPrefix(response, "id name ")
+		}
+		if response == "uciok" {
+			break
+		}
+	}
+
+	err = engine.send("isready")
+	if err != nil {
+		return nil, err
+	}
+	_, err = engine.waitFor("readyok", handshakeTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	return engine, nil
+}
+
+// Analyze starts an infinite analysis of the given position, in Forsyth-Edwards
+// Notation, stopping the previous one. onInfo receives the reports of the engine,
+// from another goroutine, and must not call the engine methods.
+func (engine *Engine) Analyze(fen string, onInfo func(info Info)) error {
+	fenUpdater, err := chess.FEN(fen)
+	if err != nil {
+		return err
+	}
+	position := chess.NewGame(fenUpdater).Position()
+
+	engine.commandMutex.Lock()
+	defer engine.commandMutex.Unlock()
+
+	err = engine.stopSearch()
+	if err != nil {
+		return err
+	}
+
+	engine.infoMutex.Lock()
+	engine.analyzedPosition = position
+	engine.onInfo = onInfo
+	engine.infoMutex.Unlock()
+
+	err = engine.send("position fen " + fen)
+	if err != nil {
+		return err
+	}
+	err = engine.send("go infinite")
+	if err != nil {
+		return err
+	}
+	engine.searching = true
+
+	return nil
+}
+
+// Evaluate searches the given position, in Forsyth-Edwards Notation, during the
+// given time, stopping the current analysis. It returns the last report of the
+// engine, whose best line has at least the best move.
+func (engine *Engine) Evaluate(fen string, searchTime time.Duration) (Info, error) {
+	fenUpdater, err := chess.FEN(fen)
+	if err != nil {
+		return Info{}, err
+	}
+	position := chess.NewGame(fenUpdater).Position()
+
+	engine.commandMutex.Lock()
+	defer engine.commandMutex.Unlock()
+
+	err = engine.stopSearch()
+	if err != nil {
+		return Info{}, err
+	}
+
+	var lastInfo Info
+	engine.infoMutex.Lock()
+	engine.analyzedPosition = position
+	engine.onInfo = func(info Info) {
+		lastInfo = info
+	}
+	engine.infoMutex.Unlock()
+
+	err = engine.send("position fen " + fen)
+	if err != nil {
+		return Info{}, err
+	}
+	err = engine.send(fmt.Sprintf("go movetime %d", searchTime.Milliseconds()))
+	if err != nil {
+		return Info{}, err
+	}
+	// Until its best move arrives, the search has to be stopped, so that a
+	// late best move is not taken for the one of the next search.
+	engine.searching = true
+	bestMoveLine, err := engine.waitFor("bestmove", searchTime+stopTimeout)
+	if err == nil {
+		engine.searching = false
+	}
+
+	engine.infoMutex.Lock()
+	engine.onInfo = nil
+	result := lastInfo
+	engine.infoMutex.Unlock()
+
+	if err != nil {
+		return Info{}, err
+	}
+
+	if len(result.PvMoves) == 0 {
+		bestMoveFields := strings.Fields(bestMoveLine)
+		if len(bestMoveFields) > 1 {
+			result.Pv, result.PvMoves = convertPv(bestMoveFields[1:2], position)
+		}
+	}
+
+	return result, nil
+}
+
+// Stop stops the current analysis, if any.
+func (engine *Engine) Stop() error {
+	engine.commandMutex.Lock()
+	defer engine.commandMutex.Unlock()
+
+	return engine.stopSearch()
+}
+
+// Close asks the engine to quit, killing its process if it does not in time.
+func (engine *Engine) Close() error {
+	engine.commandMutex.Lock()
+	defer engine.commandMutex.Unlock()
+
+	_ = engine.stopSearch()
+	_ = engine.send("quit")
+	_ = engine.input.Close()
+
+	if engine.process == nil {
+		return nil
+	}
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- engine.process.Wait()
+	}()
+	select {
+	case err := <-exited:
+		return err
+	case <-time.After(stopTimeout):
+		return engine.process.Process.Kill()
+	}
+}
+
+func (engine *Engine) stopSearch() error {
+	engine.infoMutex.Lock()
+	engine.onInfo = nil
+	engine.infoMutex.Unlock()
+
+	if !engine.searching {
+		return nil
+	}
+	engine.searching = false
+
+	err := engine.send("stop")
+	if err != nil {
+		return err
+	}
+	_, err = engine.waitFor("bestmove", stopTimeout)
+	return err
+}
+
+func (engine *Engine) send(command string) error {
+	_, err := io.WriteString(engine.input, command+"\n")
+	return err
+}
+
+// waitFor returns the first response starting with the given prefix, skipping the others.
+func (engine *Engine) waitFor(prefix string, timeout time.Duration) (string, error) {
+	deadline := time.After(timeout)
+	for {
+		select {
+		case response, open := <-engine.responses:
+			if !open {
+				return "", ErrClosed
+			}
+			if strings.HasPrefix(response, prefix) {
+				return response, nil
+			}
+		case <-deadline:
+			return "", ErrTimeout
+		}
+	}
+}
+
+func (engine *Engine) readOutput(output io.Reader) {
+	scanner := bufio.NewScanner(output)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "info ") {
+			engine.handleInfo(line)
+			continue
+		}
+
+		select {
+		case engine.responses <- line:
+		default:
+			// Nobody is waiting for so many responses.
+		}
+	}
+	close(engine.responses)
+}
+
+func (engine *Engine) handleInfo(line string) {
+	engine.infoMutex.Lock()
+	defer engine.infoMutex.Unlock()
+
+	if engine.onInfo == nil {
+		return
+	}
+	info, hasPv := parseInfo(line, engine.analyzedPosition)
+	if hasPv {
+		engine.onInfo(info)
+	}
+}
+
+// parseInfo reads an info line about the given position, and says whether it has a best line.
+func parseInfo(line string, position *chess.Position) (Info, bool) {
+	info := Info{}
+	fields := strings.Fields(line)
+	hasPv := false
+
+	for fieldIndex := 1; fieldIndex < len(fields); fieldIndex++ {
+		switch fields[fieldIndex] {
+		case "depth":
+			if fieldIndex+1 < len(fields) {
+				info.Depth, _ = strconv.Atoi(fields[fieldIndex+1])
+				fieldIndex++
+			}
+		case "score":
+			if fieldIndex+2 < len(fields) {
+				value, _ := strconv.Atoi(fields[fieldIndex+2])
+				if fields[fieldIndex+1] == "mate" {
+					info.Mate = value
+				} else {
+					info.Score = value
+				}
+				fieldIndex += 2
+			}
+		case "pv":
+			hasPv = true
+			info.Pv, info.PvMoves = convertPv(fields[fieldIndex+1:], position)
+			fieldIndex = len(fields)
+		}
+	}
+
+	return info, hasPv
+}
+
+// convertPv converts a line in UCI notation to Standard Algebraic Notation,
+// stopping at the first illegal move.
+func convertPv(uciMoves []string, position *chess.Position) ([]string, []*chess.Move) {
+	sanMoves := []string{}
+	moves := []*chess.Move{}
+
+	for _, uciMove := range uciMoves {
+		decodedMove, err := chess.UCINotation{}.Decode(position, uciMove)
+		if err != nil {
+			break
+		}
+		move := findValidMove(position, decodedMove)
+		if move == nil {
+			break
+		}
+		sanMoves = append(sanMoves, chess.AlgebraicNotation{}.Encode(position, move))
+		moves = append(moves, move)
+		position = position.Update(move)
+	}
+
+	return sanMoves, moves
+}
+
+// findValidMove returns the legal move matching the given one, with its tags set, or nil.
+func findValidMove(position *chess.Position, move *chess.Move) *chess.Move {
+	for _, validMove := range position.ValidMoves() {
+		if validMove.String() == move.String() {
+			return validMove
+		}
+	}
+	return nil
+}
+
+// FormatScore formats the score of the given info from White's point of view,
+// as "+1.25" or "#-3".
+func FormatScore(info Info, sideToMove chess.Color) string {
+	sign := 1
+	if sideToMove == chess.Black {
+		sign = -1
+	}
+	if info.Mate != 0 {
+		return fmt.Sprintf("#%d", sign*info.Mate)
+	}
+	return fmt.Sprintf("%+.2f", float64(sign*info.Score)/100)
+}
@@ -0,0 +1,126 @@
+package engine_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/notnil/chess"
+
+	"github.com/loloof64/chess-pgn-reviser-fyne/engine"
+	"github.com/loloof64/chess-pgn-reviser-fyne/engine/enginetest"
+)
+
+func TestAnalyzeStreamsInfosInSan(t *testing.T) {
+	fake := &enginetest.FakeEngine{
+		Name: "Fake engine",
+		Infos: []string{
+			"info string starting",
+			"info depth 12 seldepth 18 score cp 34 nodes 1000 pv e2e4 e7e5 g1f3",
+			"info depth 20 score mate -3 pv d2d4 d7d5 z9z9",
+		},
+		BestMove: "e2e4",
+	}
+	analysisEngine, err := engine.Connect(fake.Connect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if analysisEngine.Name != "Fake engine" {
+		t.Errorf("name is %q", analysisEngine.Name)
+	}
+
+	infos := make(chan engine.Info, 10)
+	fen := chess.StartingPosition().String()
+	err = analysisEngine.Analyze(fen, func(info engine.Info) {
+		infos <- info
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := receive(t, infos)
+	if first.Depth != 12 || first.Score != 34 || first.Mate != 0 {
+		t.Errorf("first info is %+v", first)
+	}
+	if !reflect.DeepEqual(first.Pv, []string{"e4", "e5", "Nf3"}) {
+		t.Errorf("first pv is %v", first.Pv)
+	}
+
+	second := receive(t, infos)
+	// The best line stops at the first illegal move.
+	if second.Mate != -3 || !reflect.DeepEqual(second.Pv, []string{"d4", "d5"}) {
+		t.Errorf("second info is %+v", second)
+	}
+	if engine.FormatScore(second, chess.White) != "#-3" {
+		t.Errorf("formatted score is %s", engine.FormatScore(second, chess.White))
+	}
+
+	err = analysisEngine.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake.Wait()
+
+	expectedCommands := []string{"uci", "isready", "position fen " + fen, "go infinite", "stop", "quit"}
+	if !reflect.DeepEqual(fake.Commands(), expectedCommands) {
+		t.Errorf("commands are %v", fake.Commands())
+	}
+}
+
+func receive(t *testing.T, infos chan engine.Info) engine.Info {
+	t.Helper()
+	select {
+	case info := <-infos:
+		return info
+	case <-time.After(time.Second):
+		t.Fatal("no info received")
+		return engine.Info{}
+	}
+}
+
+func TestEvaluateReturnsTheLastInfo(t *testing.T) {
+	fake := &enginetest.FakeEngine{
+		Infos: []string{
+			"info depth 1 score cp 10 pv d2d4",
+			"info depth 2 score mate 2",
+		},
+		BestMove: "e2e4",
+	}
+	analysisEngine, err := engine.Connect(fake.Connect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer analysisEngine.Close()
+
+	info, err := analysisEngine.Evaluate(chess.StartingPosition().String(), 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Depth != 1 || info.Centipawns() != 10 || !reflect.DeepEqual(info.Pv, []string{"d4"}) {
+		t.Errorf("info is %+v", info)
+	}
+}
+
+func TestEvaluateStopsASearchGoingPastItsTime(t *testing.T) {
+	fake := &enginetest.FakeEngine{BestMove: "e2e4", Stalled: true}
+	analysisEngine, err := engine.Connect(fake.Connect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer analysisEngine.Close()
+
+	_, err = analysisEngine.Evaluate(chess.StartingPosition().String(), 10*time.Millisecond)
+	if err != engine.ErrTimeout {
+		t.Fatalf("got the error %v, expected %v", err, engine.ErrTimeout)
+	}
+
+	// The late best move is the answer to the stop command.
+	err = analysisEngine.Stop()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commands := fake.Commands()
+	if lastCommand := commands[len(commands)-1]; lastCommand != "stop" {
+		t.Errorf("got the last command %q, expected stop", lastCommand)
+	}
+}
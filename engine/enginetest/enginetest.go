@@ -0,0 +1,92 @@
+// Package enginetest provides a scripted UCI engine, for testing without engine binary.
+package enginetest
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"sync"
+)
+
+// FakeEngine is a scripted UCI engine, answering the handshake commands, and
+// sending its info lines on each search.
+type FakeEngine struct {
+	// Name is sent in the id name line.
+	Name string
+
+	// Infos are the lines sent when a search starts.
+	Infos []string
+
+	// BestMove is sent, in UCI notation, when a search is stopped, or right
+	// after the infos for a search which is not infinite.
+	BestMove string
+
+	// Stalled makes every search send its best move only once stopped, as
+	// an engine going past the search time.
+	Stalled bool
+
+	mutex    sync.Mutex
+	commands []string
+	done     chan struct{}
+}
+
+// Connect starts the fake engine, returning its output and input, to be given to engine.Connect.
+func (fake *FakeEngine) Connect() (io.Reader, io.WriteCloser) {
+	commandsReader, commandsWriter := io.Pipe()
+	outputReader, outputWriter := io.Pipe()
+	fake.done = make(chan struct{})
+
+	go fake.answer(commandsReader, outputWriter)
+
+	return outputReader, commandsWriter
+}
+
+// Wait waits until the fake engine has received the quit command, or its input has been closed.
+func (fake *FakeEngine) Wait() {
+	<-fake.done
+}
+
+// Commands returns the commands received so far.
+func (fake *FakeEngine) Commands() []string {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	return append([]string(nil), fake.commands...)
+}
+
+func (fake *FakeEngine) answer(commands io.Reader, output *io.PipeWriter) {
+	defer close(fake.done)
+	defer output.Close()
+
+	scanner := bufio.NewScanner(commands)
+	for scanner.Scan() {
+		command := strings.TrimSpace(scanner.Text())
+		fake.mutex.Lock()
+		fake.commands = append(fake.commands, command)
+		fake.mutex.Unlock()
+
+		var answers []string
+		switch strings.SplitN(command, " ", 2)[0] {
+		case "uci":
+			answers = []string{"id name " + fake.Name, "uciok"}
+		case "isready":
+			answers = []string{"readyok"}
+		case "go":
+			answers = fake.Infos
+			if !strings.Contains(command, "infinite") && !fake.Stalled {
+				answers = append(append([]string(nil), answers...), "bestmove "+fake.BestMove)
+			}
+		case "stop":
+			answers = []string{"bestmove " + fake.BestMove}
+		case "quit":
+			return
+		}
+
+		for _, answer := range answers {
+			_, err := io.WriteString(output, answer+"\n")
+			if err != nil {
+				return
+			}
+		}
+	}
+}
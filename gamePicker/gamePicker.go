@@ -0,0 +1,203 @@
+package gamePicker
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/gookit/ini/v2"
+
+	"github.com/loloof64/chess-pgn-reviser-fyne/pgnLoader"
+)
+
+// columnsTags are the tags shown for each game, in columns order.
+var columnsTags = []string{"White", "Black", "Event", "Date", "Result", "ECO"}
+
+// columnsTitlesKeys are the locale keys of the columns titles, in columns order.
+var columnsTitlesKeys = []string{
+	"gamePicker.white",
+	"gamePicker.black",
+	"gamePicker.event",
+	"gamePicker.date",
+	"gamePicker.result",
+	"gamePicker.eco",
+}
+
+// coverageTitleKey is the locale key of the title of the last column,
+// which shows the revision coverage.
+const coverageTitleKey = "gamePicker.coverage"
+
+var randomGenerator = rand.New(rand.NewSource(time.Now().UnixNano()))
+
+// GameSummary is what the picker shows of a game.
+type GameSummary struct {
+	Tags *pgnLoader.TagPairs
+
+	// Coverage describes how much of the game has been revised, may be empty.
+	Coverage string
+
+	// Due says whether the game is due for review today.
+	Due bool
+}
+
+// GamePicker lets the user choose a game among the games of a PGN file.
+type GamePicker struct {
+	games []GameSummary
+
+	// filteredIndexes are the indexes of the games matching the filter, in file order.
+	filteredIndexes []int
+	selectedIndex   int
+	filter          string
+	dueOnly         bool
+
+	gamesList *widget.List
+}
+
+// ShowGamePicker shows a dialog listing the given games, and calls onGameSelected
+// with the index of the game chosen by the user, if any. When some games are due
+// for review, only those are listed at first.
+func ShowGamePicker(games []GameSummary, parent fyne.Window, onGameSelected func(gameIndex int)) {
+	dueGamesCount := 0
+	for _, game := range games {
+		if game.Due {
+			dueGamesCount++
+		}
+	}
+
+	picker := &GamePicker{games: games, selectedIndex: -1, dueOnly: dueGamesCount > 0}
+	picker.applyFilter()
+
+	picker.gamesList = widget.NewList(
+		func() int {
+			return len(picker.filteredIndexes)
+		},
+		func() fyne.CanvasObject {
+			return newRow()
+		},
+		func(itemIndex widget.ListItemID, item fyne.CanvasObject) {
+			game := picker.games[picker.filteredIndexes[itemIndex]]
+			cells := item.(*fyne.Container).Objects
+			for columnIndex, tag := range columnsTags {
+				cells[columnIndex].(*widget.Label).SetText(game.Tags.Get(tag))
+			}
+			cells[len(columnsTags)].(*widget.Label).SetText(game.Coverage)
+		},
+	)
+	picker.gamesList.OnSelected = func(itemIndex widget.ListItemID) {
+		picker.selectedIndex = picker.filteredIndexes[itemIndex]
+	}
+
+	filterEntry := widget.NewEntry()
+	filterEntry.SetPlaceHolder(ini.String("gamePicker.filterPlaceholder"))
+	filterEntry.OnChanged = func(filter string) {
+		picker.filter = filter
+		picker.refreshFilter()
+	}
+
+	dueOnlyCheck := widget.NewCheck(fmt.Sprintf("%s (%d)", ini.String("gamePicker.dueOnly"), dueGamesCount),
+		func(checked bool) {
+			picker.dueOnly = checked
+			picker.refreshFilter()
+		})
+	dueOnlyCheck.SetChecked(picker.dueOnly)
+
+	randomButton := widget.NewButton(ini.String("gamePicker.randomButton"), func() {
+		if len(picker.filteredIndexes) == 0 {
+			return
+		}
+		itemIndex := randomGenerator.Intn(len(picker.filteredIndexes))
+		picker.gamesList.Select(itemIndex)
+		picker.gamesList.ScrollTo(itemIndex)
+	})
+
+	header := newRow()
+	for columnIndex, cell := range header.Objects {
+		titleLabel := cell.(*widget.Label)
+		if columnIndex < len(columnsTitlesKeys) {
+			titleLabel.SetText(ini.String(columnsTitlesKeys[columnIndex]))
+		} else {
+			titleLabel.SetText(ini.String(coverageTitleKey))
+		}
+		titleLabel.TextStyle = fyne.TextStyle{Bold: true}
+	}
+
+	toolbarZone := fyne.NewContainerWithLayout(
+		layout.NewBorderLayout(nil, nil, dueOnlyCheck, randomButton),
+		dueOnlyCheck,
+		randomButton,
+		filterEntry,
+	)
+	headerZone := container.NewVBox(toolbarZone, header)
+	content := fyne.NewContainerWithLayout(
+		layout.NewBorderLayout(headerZone, nil, nil, nil),
+		headerZone,
+		picker.gamesList,
+	)
+
+	dialogTitle := ini.String("gamePicker.dialogTitle")
+	confirmButtonText := ini.String("general.okButton")
+	cancelButtonText := ini.String("general.cancelButton")
+
+	pickerDialog := dialog.NewCustomConfirm(dialogTitle, confirmButtonText,
+		cancelButtonText, content, func(confirmed bool) {
+			if confirmed && picker.selectedIndex >= 0 {
+				onGameSelected(picker.selectedIndex)
+			}
+		}, parent)
+	pickerDialog.Resize(fyne.NewSize(800, 500))
+	pickerDialog.Show()
+}
+
+// newRow creates a row with a cell for each tag column, and one for the coverage.
+func newRow() *fyne.Container {
+	columnsCount := len(columnsTags) + 1
+	row := fyne.NewContainerWithLayout(layout.NewGridLayout(columnsCount))
+	for columnIndex := 0; columnIndex < columnsCount; columnIndex++ {
+		cell := widget.NewLabel("")
+		cell.Wrapping = fyne.TextTruncate
+		row.AddObject(cell)
+	}
+	return row
+}
+
+func (picker *GamePicker) refreshFilter() {
+	picker.applyFilter()
+	picker.selectedIndex = -1
+	picker.gamesList.UnselectAll()
+	picker.gamesList.Refresh()
+}
+
+// applyFilter keeps the games having at least one column containing the filter,
+// ignoring case, and only the due ones if asked.
+func (picker *GamePicker) applyFilter() {
+	filter := strings.ToLower(strings.TrimSpace(picker.filter))
+	picker.filteredIndexes = nil
+
+	for gameIndex, game := range picker.games {
+		if picker.dueOnly && !game.Due {
+			continue
+		}
+		if picker.matchesFilter(game.Tags, filter) {
+			picker.filteredIndexes = append(picker.filteredIndexes, gameIndex)
+		}
+	}
+}
+
+func (picker *GamePicker) matchesFilter(gameTags *pgnLoader.TagPairs, filter string) bool {
+	if filter == "" {
+		return true
+	}
+	for _, tag := range columnsTags {
+		if strings.Contains(strings.ToLower(gameTags.Get(tag)), filter) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+)
+
+// gameLayout lays out the board zone and the history zone, in this order.
+// The history goes beside the board in a landscape window, and below it in
+// a portrait one. The board zone grows as much as its square board can.
+type gameLayout struct {
+	// board is the chess board inside the board zone, which must stay square.
+	board fyne.CanvasObject
+}
+
+func newGameLayout(board fyne.CanvasObject) fyne.Layout {
+	return &gameLayout{board: board}
+}
+
+// Layout gives the board zone the largest square board fitting beside, or
+// above, the minimum size of the history zone, which gets the remaining space.
+func (gameLayout *gameLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
+	boardZone, historyZone := objects[0], objects[1]
+	boardMinLength := gameLayout.board.MinSize().Width
+	// margins are the parts of the board zone around the board, such as the move entry.
+	margins := boardZone.MinSize().Subtract(gameLayout.board.MinSize())
+	historyMinSize := historyZone.MinSize()
+	padding := theme.Padding()
+
+	// The history goes below the board only if the board keeps its minimum size there.
+	landscape := size.Width >= size.Height ||
+		size.Height-historyMinSize.Height-padding-margins.Height < boardMinLength
+	if landscape {
+		boardLength := fyne.Min(size.Height-margins.Height, size.Width-historyMinSize.Width-padding-margins.Width)
+		boardLength = fyne.Max(boardLength, boardMinLength)
+		boardZoneWidth := boardLength + margins.Width
+
+		boardZone.Resize(fyne.NewSize(boardZoneWidth, boardLength+margins.Height))
+		boardZone.Move(fyne.NewPos(0, 0))
+		historyZone.Resize(fyne.NewSize(size.Width-boardZoneWidth-padding, size.Height))
+		historyZone.Move(fyne.NewPos(boardZoneWidth+padding, 0))
+		return
+	}
+
+	boardLength := fyne.Min(size.Width-margins.Width, size.Height-historyMinSize.Height-padding-margins.Height)
+	boardLength = fyne.Max(boardLength, boardMinLength)
+	boardZoneHeight := boardLength + margins.Height
+
+	// The board zone is centered above the history.
+	boardZoneWidth := boardLength + margins.Width
+	boardZone.Resize(fyne.NewSize(boardZoneWidth, boardZoneHeight))
+	boardZone.Move(fyne.NewPos((size.Width-boardZoneWidth)/2, 0))
+	historyZone.Resize(fyne.NewSize(size.Width, size.Height-boardZoneHeight-padding))
+	historyZone.Move(fyne.NewPos(0, boardZoneHeight+padding))
+}
+
+// MinSize returns the size of the side by side layout with the minimum sizes of the zones.
+func (gameLayout *gameLayout) MinSize(objects []fyne.CanvasObject) fyne.Size {
+	boardZoneMinSize, historyMinSize := objects[0].MinSize(), objects[1].MinSize()
+	return fyne.NewSize(boardZoneMinSize.Width+theme.Padding()+historyMinSize.Width,
+		fyne.Max(boardZoneMinSize.Height, historyMinSize.Height))
+}
@@ -1,6 +1,6 @@
 module github.com/loloof64/chess-pgn-reviser-fyne
 
-go 1.14
+go 1.16
 
 require (
 	fyne.io/fyne/v2 v2.1.4
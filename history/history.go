@@ -13,20 +13,25 @@ import (
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 
+	"github.com/notnil/chess"
+
 	"github.com/loloof64/chess-pgn-reviser-fyne/commonTypes"
 )
 
 // HistoryLayout defines the layout of the History widget.
+// The moves wrap at the width of the widget, which follows its resizes.
 type HistoryLayout struct {
 	width float32
 	gap   fyne.Size
 }
 
-func (l HistoryLayout) MinSize(objects []fyne.CanvasObject) fyne.Size {
+func (l *HistoryLayout) MinSize(objects []fyne.CanvasObject) fyne.Size {
 	currMaxH, w, h := float32(0), float32(0), float32(0)
+	maxChildWidth := float32(0)
 
 	for _, obj := range objects {
 		childSize := obj.MinSize()
+		maxChildWidth = fyne.Max(maxChildWidth, childSize.Width)
 
 		wontlOverflowCurrentLine := w+childSize.Width <= l.width
 
@@ -46,10 +51,11 @@ func (l HistoryLayout) MinSize(objects []fyne.CanvasObject) fyne.Size {
 	}
 
 	// We must not forget last line !
-	return fyne.NewSize(l.width, h+currMaxH)
+	// Only the widest move is needed, as the moves wrap at any width.
+	return fyne.NewSize(maxChildWidth, h+currMaxH)
 }
 
-func (l HistoryLayout) Layout(objects []fyne.CanvasObject, containerSize fyne.Size) {
+func (l *HistoryLayout) Layout(objects []fyne.CanvasObject, containerSize fyne.Size) {
 	pos := fyne.NewPos(0, 0)
 	w, h, currMaxH := float32(0), float32(0), float32(0)
 
@@ -78,26 +84,31 @@ func (l HistoryLayout) Layout(objects []fyne.CanvasObject, containerSize fyne.Si
 	}
 }
 
-func newHistoryLayout(width float32) HistoryLayout {
-	return HistoryLayout{width: width, gap: fyne.NewSize(5, 8)}
+func newHistoryLayout(width float32) *HistoryLayout {
+	return &HistoryLayout{width: width, gap: fyne.NewSize(5, 8)}
 }
 
 // History is a widget that shows the played moves, and is intended to
 // load selected position on the board if game is not in progress.
+// Moves are kept as a tree: variations are shown between brackets, after
+// the move they are an alternative to.
 type History struct {
 	widget.BaseWidget
 
-	preferredSize     fyne.Size
-	currentMoveNumber int
+	preferredSize fyne.Size
 
+	layout            *HistoryLayout
 	container         *fyne.Container
 	onPositionRequest func(moveData commonTypes.GameMove) bool
 
-	currentHighlightedButtonIndex int
-	currentMoveDataIndex          int
+	// root holds the start position, its children being the first moves.
+	root *commonTypes.GameMove
+	// currentNode is the selected move, or root for the start position.
+	currentNode *commonTypes.GameMove
+	// lineEnd is the move after which AddMove adds the next one.
+	lineEnd *commonTypes.GameMove
 
-	allMovesData  []commonTypes.GameMove
-	startPosition string
+	moveZones map[*commonTypes.GameMove]*fyne.Container
 }
 
 type historyRenderer struct {
@@ -136,7 +147,9 @@ func NewHistory(preferredSize fyne.Size) *History {
 	history := &History{preferredSize: preferredSize}
 	history.ExtendBaseWidget(history)
 
-	history.container = fyne.NewContainerWithLayout(newHistoryLayout(preferredSize.Width))
+	history.layout = newHistoryLayout(preferredSize.Width)
+	history.container = fyne.NewContainerWithLayout(history.layout)
+	history.Clear(chess.StartingPosition().String())
 
 	return history
 }
@@ -147,223 +160,278 @@ func (history *History) SetOnPositionRequestHandler(handler func(moveData common
 	history.onPositionRequest = handler
 }
 
+// Resize resizes the history, wrapping the moves at the new width. The
+// history grows taller if the moves need more lines at this width.
+func (history *History) Resize(size fyne.Size) {
+	if size.Width != history.layout.width {
+		history.layout.width = size.Width
+		size.Height = fyne.Max(size.Height, history.layout.MinSize(history.container.Objects).Height)
+	}
+	history.BaseWidget.Resize(size)
+}
+
 // CreateRenderer creates the Renderer for History widget.
 func (history *History) CreateRenderer() fyne.WidgetRenderer {
 	renderer := &historyRenderer{history: history}
 	return renderer
 }
 
-// AddMove adds a move to the History widget.
+// AddMove adds a move to the History widget, after the last added move, and selects it.
 func (history *History) AddMove(moveData commonTypes.GameMove) {
-	var moveComponent *fyne.Container
-	history.currentHighlightedButtonIndex += 1
-	thisButtonIndex := history.currentHighlightedButtonIndex
-	// Indeed, for now, we haven't added the current move yet.
-	thisMoveDataIndex := len(history.allMovesData)
-
-	moveButton := widget.NewButton(moveData.Fan, func() {
-		if history.onPositionRequest != nil {
-			if history.onPositionRequest(moveData) {
-				history.currentHighlightedButtonIndex = thisButtonIndex
-				history.currentMoveDataIndex = thisMoveDataIndex
-				history.updateButtonsStyles()
-			}
-		}
-	})
-	moveComponent = container.New(
-		layout.NewMaxLayout(),
-		canvas.NewRectangle(color.Transparent),
-		moveButton,
-	)
-
-	history.container.AddObject(moveComponent)
-	history.container.Resize(history.preferredSize)
-	history.allMovesData = append(history.allMovesData, moveData)
-	if moveData.IsBlackMove {
-		history.currentMoveNumber += 1
-		// Though it is not a button, we need to update this index
-		// as buttons and labels are in the same array.
-		history.currentHighlightedButtonIndex += 1
-		numberComponent := widget.NewLabel(fmt.Sprintf("%v.", history.currentMoveNumber))
-		history.container.AddObject(numberComponent)
-		history.container.Resize(history.preferredSize)
-		history.Refresh()
+	node := moveData
+	node.Parent = history.lineEnd
+	node.Children = nil
+	history.lineEnd.Children = append(history.lineEnd.Children, &node)
+	history.lineEnd = &node
+	history.currentNode = &node
+
+	history.rebuild()
+}
+
+// Clear clears all moves from the History widget, then adds the given moves,
+// already played from the start position, the next moves going after them.
+func (history *History) Clear(startPositionFen string, playedMoves ...commonTypes.GameMove) {
+	history.root = &commonTypes.GameMove{Fen: startPositionFen}
+	history.lineEnd = history.root
+	for _, moveData := range playedMoves {
+		node := moveData
+		node.Parent = history.lineEnd
+		node.Children = nil
+		history.lineEnd.Children = append(history.lineEnd.Children, &node)
+		history.lineEnd = &node
 	}
+	history.currentNode = history.lineEnd
+	history.moveZones = map[*commonTypes.GameMove]*fyne.Container{}
+
+	history.rebuild()
 }
 
-// Clear clears all moves from the History widget.
-func (history *History) Clear(startPositionFen string) {
-	history.container.Objects = nil
-	history.allMovesData = nil
-	history.startPosition = startPositionFen
-	positionParts := strings.Split(startPositionFen, " ")
-	startMoveNumber, err := strconv.Atoi(positionParts[len(positionParts)-1])
-	if err != nil {
-		startMoveNumber = 1
+// MergeMovesTree adds the moves of the given tree which are missing from the
+// History widget, as variations. The root of the tree must hold the same start
+// position as the History widget, otherwise nothing is done.
+func (history *History) MergeMovesTree(root *commonTypes.GameMove) {
+	if root.Fen != history.root.Fen {
+		return
 	}
-	// Though it is not a button, we need to update this index
-	// as buttons and labels are in the same array.
-	history.currentHighlightedButtonIndex = 0
-	history.currentMoveDataIndex = -1
-	history.currentMoveNumber = startMoveNumber
-	numberComponent := widget.NewLabel(fmt.Sprintf("%v.", history.currentMoveNumber))
-	history.container.AddObject(numberComponent)
-	history.container.Resize(history.preferredSize)
-	history.Refresh()
+	mergeAnnotations(history.root, root)
+	mergeChildren(history.root, root)
+
+	history.rebuild()
 }
 
-// Tries to select the start position.
-func (history *History) RequestStartPositionSelection() {
-	history.currentHighlightedButtonIndex = -1
-	history.currentMoveDataIndex = -1
-	history.updateButtonsStyles()
+// MovesTree returns the root of the moves tree, holding the start position.
+func (history *History) MovesTree() *commonTypes.GameMove {
+	return history.root
+}
 
-	positionToRequest := commonTypes.GameMove{}
-	positionToRequest.Fen = history.startPosition
-	if history.onPositionRequest != nil {
-		_ = history.onPositionRequest(positionToRequest)
-	}
+// SetDrawings replaces the drawings of the selected move, or of the start
+// position if no move is selected.
+func (history *History) SetDrawings(drawings []commonTypes.Drawing) {
+	history.currentNode.Drawings = drawings
 }
 
-// Tries to select the last element.
-func (history *History) RequestLastItemSelection() {
-	lastButtonIndex := history.findLastButtonIndex()
-	lastMoveDataIndex := history.findLastMoveDataIndex()
+// Comment returns the comment of the selected move, or of the start position
+// if no move is selected.
+func (history *History) Comment() string {
+	return strings.Join(history.currentNode.Comments, " ")
+}
 
-	if lastButtonIndex < 0 || lastMoveDataIndex < 0 {
+// SetComment replaces the comment of the selected move, or of the start
+// position if no move is selected. A blank text removes the comment.
+func (history *History) SetComment(text string) {
+	text = strings.Join(strings.Fields(text), " ")
+	if text == "" {
+		history.currentNode.Comments = nil
 		return
 	}
-	if history.onPositionRequest != nil {
-		if history.onPositionRequest(history.allMovesData[lastMoveDataIndex]) {
-			history.currentHighlightedButtonIndex = lastButtonIndex
-			history.currentMoveDataIndex = lastMoveDataIndex
-			history.updateButtonsStyles()
-		}
-	}
+	history.currentNode.Comments = []string{text}
 }
 
-// Tries to select the previous element, or to load start position
-func (history *History) RequestPreviousItemSelection() {
-	previousButtonIndex := history.findPreviousButtonIndex()
-	previousMoveDataIndex := history.findPreviousLastMoveDataIndex()
-
-	if history.onPositionRequest != nil {
-		weAreOutsideMoves := previousButtonIndex < 0 || previousMoveDataIndex < 0
-		if weAreOutsideMoves {
-			positionToRequest := commonTypes.GameMove{}
-			positionToRequest.Fen = history.startPosition
-			if history.onPositionRequest(positionToRequest) {
-				history.currentHighlightedButtonIndex = -1
-				history.currentMoveDataIndex = -1
-				history.updateButtonsStyles()
+func mergeChildren(target *commonTypes.GameMove, source *commonTypes.GameMove) {
+	for _, sourceChild := range source.Children {
+		var matchingChild *commonTypes.GameMove
+		for _, targetChild := range target.Children {
+			if targetChild.Fen == sourceChild.Fen {
+				matchingChild = targetChild
+				break
 			}
+		}
+
+		if matchingChild == nil {
+			newChild := *sourceChild
+			newChild.Parent = target
+			newChild.Children = nil
+			matchingChild = &newChild
+			target.Children = append(target.Children, matchingChild)
 		} else {
-			if history.onPositionRequest(history.allMovesData[previousMoveDataIndex]) {
-				history.currentHighlightedButtonIndex = previousButtonIndex
-				history.currentMoveDataIndex = previousMoveDataIndex
-				history.updateButtonsStyles()
-			}
+			mergeAnnotations(matchingChild, sourceChild)
 		}
+
+		mergeChildren(matchingChild, sourceChild)
 	}
 }
 
-// Tries to select the next element
-func (history *History) RequestNextItemSelection() {
-	nextButtonIndex := history.findNextButtonIndex()
-	nextMoveDataIndex := history.findNextLastMoveDataIndex()
+// mergeAnnotations gives the NAGs, comments and drawings of the source node to
+// the target one, unless it already has its own.
+func mergeAnnotations(target *commonTypes.GameMove, source *commonTypes.GameMove) {
+	if len(target.Nags) == 0 {
+		target.Nags = source.Nags
+	}
+	if len(target.Comments) == 0 {
+		target.Comments = source.Comments
+	}
+	if len(target.Drawings) == 0 {
+		target.Drawings = source.Drawings
+	}
+}
 
-	if history.onPositionRequest != nil {
-		weAreOutsideMoves := nextButtonIndex < 0 || nextMoveDataIndex < 0
-		if weAreOutsideMoves {
-			return
-		} else {
-			if history.onPositionRequest(history.allMovesData[nextMoveDataIndex]) {
-				history.currentHighlightedButtonIndex = nextButtonIndex
-				history.currentMoveDataIndex = nextMoveDataIndex
-				history.updateButtonsStyles()
+// Tries to select the start position.
+func (history *History) RequestStartPositionSelection() {
+	history.requestNode(history.root)
+}
+
+// Tries to select the move reached by playing, from the start position, the
+// moves leading to the given positions in turn.
+func (history *History) RequestPathSelection(positionsFens []string) {
+	node := history.root
+	for _, fen := range positionsFens {
+		var matchingChild *commonTypes.GameMove
+		for _, child := range node.Children {
+			if child.Fen == fen {
+				matchingChild = child
+				break
 			}
 		}
+		if matchingChild == nil {
+			return
+		}
+		node = matchingChild
 	}
+	history.requestNode(node)
 }
 
-func (history *History) findLastMoveDataIndex() int {
-	var lastMoveDataIndex int
-	for index := range history.allMovesData {
-		lastMoveDataIndex = index
+// Tries to select the last move of the current line.
+func (history *History) RequestLastItemSelection() {
+	lastNode := history.currentNode
+	for len(lastNode.Children) > 0 {
+		lastNode = lastNode.Children[0]
 	}
-	return lastMoveDataIndex
+	history.requestNode(lastNode)
 }
 
-func (history *History) findLastButtonIndex() int {
-	var lastButtonIndex int
-	for index, currentObject := range history.container.Objects {
-		_, ok := currentObject.(*fyne.Container)
-		if ok {
-			lastButtonIndex = index
-		}
+// Tries to select the previous element, or to load start position
+func (history *History) RequestPreviousItemSelection() {
+	if history.currentNode.Parent != nil {
+		history.requestNode(history.currentNode.Parent)
 	}
-
-	return lastButtonIndex
 }
 
-func (history *History) findPreviousLastMoveDataIndex() int {
-	if history.currentMoveDataIndex < 0 {
-		return -1
-	} else {
-		return history.currentMoveDataIndex - 1
+// Tries to select the next element of the current line.
+func (history *History) RequestNextItemSelection() {
+	if len(history.currentNode.Children) > 0 {
+		history.requestNode(history.currentNode.Children[0])
 	}
 }
 
-func (history *History) findPreviousButtonIndex() int {
-	if history.currentHighlightedButtonIndex < 0 {
-		return -1
+// Tries to select the first move of the first variation replacing the next move.
+func (history *History) RequestEnterVariation() {
+	if len(history.currentNode.Children) > 1 {
+		history.requestNode(history.currentNode.Children[1])
 	}
-	for index := history.currentHighlightedButtonIndex - 1; index >= 0; index-- {
-		_, ok := history.container.Objects[index].(*fyne.Container)
-		if ok {
-			return index
+}
+
+// Tries to select the move replaced by the variation holding the selected move.
+func (history *History) RequestLeaveVariation() {
+	for node := history.currentNode; node.Parent != nil; node = node.Parent {
+		if node != node.Parent.Children[0] {
+			history.requestNode(node.Parent.Children[0])
+			return
 		}
 	}
-	return -1
 }
 
-func (history *History) findNextLastMoveDataIndex() int {
-	if history.currentMoveDataIndex >= len(history.allMovesData)-1 {
-		return len(history.allMovesData) - 1
-	} else if len(history.allMovesData) == 0 {
-		return -1
-	} else {
-		return history.currentMoveDataIndex + 1
+func (history *History) requestNode(node *commonTypes.GameMove) {
+	if history.onPositionRequest == nil {
+		return
+	}
+	if history.onPositionRequest(*node) {
+		history.currentNode = node
+		history.updateButtonsStyles()
 	}
 }
 
-func (history *History) findNextButtonIndex() int {
-	for index := history.currentHighlightedButtonIndex + 1; index < len(history.container.Objects); index++ {
-		_, ok := history.container.Objects[index].(*fyne.Container)
-		if ok {
-			return index
+// rebuild lays out again all the moves, creating the buttons of the new ones.
+func (history *History) rebuild() {
+	history.container.Objects = nil
+	history.addLine(history.root, false, true)
+	history.container.Resize(fyne.NewSize(history.layout.width, history.preferredSize.Height))
+	history.updateButtonsStyles()
+}
+
+// addLine adds the moves following the given one, with their variations between brackets.
+func (history *History) addLine(lineStart *commonTypes.GameMove, inVariation bool, needsMoveNumber bool) {
+	for node := lineStart; len(node.Children) > 0; node = node.Children[0] {
+		history.addMove(node.Children[0], inVariation, needsMoveNumber)
+		needsMoveNumber = false
+
+		for _, variationStart := range node.Children[1:] {
+			history.container.AddObject(widget.NewLabel("("))
+			history.addMove(variationStart, true, true)
+			history.addLine(variationStart, true, false)
+			history.container.AddObject(widget.NewLabel(")"))
+			needsMoveNumber = true
 		}
 	}
+}
+
+// addMove adds the button of the given move, preceded by the move number
+// for a white move, or when needed for a black move.
+func (history *History) addMove(node *commonTypes.GameMove, inVariation bool, needsMoveNumber bool) {
+	moveNumber := moveNumberOf(node.Parent.Fen)
+	if !node.IsBlackMove {
+		history.container.AddObject(widget.NewLabel(fmt.Sprintf("%v.", moveNumber)))
+	} else if needsMoveNumber {
+		history.container.AddObject(widget.NewLabel(fmt.Sprintf("%v...", moveNumber)))
+	}
 
-	return -1
+	moveZone, found := history.moveZones[node]
+	if !found {
+		moveButton := widget.NewButton(node.Fan, func() {
+			history.requestNode(node)
+		})
+		if inVariation {
+			moveButton.Importance = widget.LowImportance
+		}
+		moveZone = container.New(
+			layout.NewMaxLayout(),
+			canvas.NewRectangle(color.Transparent),
+			moveButton,
+		)
+		history.moveZones[node] = moveZone
+	}
+	history.container.AddObject(moveZone)
+}
+
+// moveNumberOf returns the number of the move to be played from the given position.
+func moveNumberOf(fen string) int {
+	positionParts := strings.Split(fen, " ")
+	moveNumber, err := strconv.Atoi(positionParts[len(positionParts)-1])
+	if err != nil {
+		return 1
+	}
+	return moveNumber
 }
 
 func (history *History) updateButtonsStyles() {
-	for index, currentObject := range history.container.Objects {
-		currentButtonZone, buttonZoneOk := currentObject.(*fyne.Container)
-		if buttonZoneOk {
-			_, buttonOk := currentButtonZone.Objects[1].(*widget.Button)
-			if buttonOk {
-				var fillColor color.Color
-				if index == history.currentHighlightedButtonIndex {
-					fillColor = color.NRGBA{R: 100, G: 30, B: 255, A: 255}
-				} else {
-					fillColor = color.Transparent
-				}
-				var currentButtonBackground = currentButtonZone.Objects[0].(*canvas.Rectangle)
-				currentButtonBackground.FillColor = fillColor
-			}
+	for node, moveZone := range history.moveZones {
+		var fillColor color.Color
+		if node == history.currentNode {
+			fillColor = color.NRGBA{R: 100, G: 30, B: 255, A: 255}
+		} else {
+			fillColor = color.Transparent
 		}
+		var currentButtonBackground = moveZone.Objects[0].(*canvas.Rectangle)
+		currentButtonBackground.FillColor = fillColor
 	}
 	history.Refresh()
 }
@@ -0,0 +1,69 @@
+package history
+
+import (
+	"github.com/notnil/chess"
+
+	"github.com/loloof64/chess-pgn-reviser-fyne/commonTypes"
+	"github.com/loloof64/chess-pgn-reviser-fyne/pgnLoader"
+)
+
+// NewMovesTree converts the moves tree of a parsed game, variations included,
+// so that it can be merged into the History widget. The returned root holds
+// the start position.
+func NewMovesTree(game *pgnLoader.Game) *commonTypes.GameMove {
+	root := &commonTypes.GameMove{Fen: game.Root.Position.String()}
+	root.Comments, root.Drawings = convertComments(game.Root.Comments)
+	addChildren(root, game.Root)
+	return root
+}
+
+// NewMovesLine converts the given moves of a parsed game, which must follow
+// each other, so that they can be given to the History widget as already
+// played moves.
+func NewMovesLine(moves []*pgnLoader.MoveNode) []commonTypes.GameMove {
+	line := make([]commonTypes.GameMove, 0, len(moves))
+	for _, move := range moves {
+		line = append(line, *convertMove(move))
+	}
+	return line
+}
+
+func addChildren(target *commonTypes.GameMove, source *pgnLoader.MoveNode) {
+	for _, sourceChild := range source.Children {
+		child := convertMove(sourceChild)
+		child.Parent = target
+		target.Children = append(target.Children, child)
+		addChildren(child, sourceChild)
+	}
+}
+
+// convertMove converts a move of a parsed game, without its children.
+func convertMove(source *pgnLoader.MoveNode) *commonTypes.GameMove {
+	san := chess.AlgebraicNotation{}.Encode(source.Parent.Position, source.Move)
+	move := &commonTypes.GameMove{
+		Fan:                commonTypes.ConvertSanToFan(san, source.IsWhiteMove()),
+		San:                san,
+		Fen:                source.Position.String(),
+		LastMoveOriginCell: commonTypes.Cell{File: int8(source.Move.S1().File()), Rank: int8(source.Move.S1().Rank())},
+		LastMoveTargetCell: commonTypes.Cell{File: int8(source.Move.S2().File()), Rank: int8(source.Move.S2().Rank())},
+		IsBlackMove:        !source.IsWhiteMove(),
+		Nags:               source.Nags,
+	}
+	move.Comments, move.Drawings = convertComments(source.Comments)
+	return move
+}
+
+// convertComments separates the drawings of the comments from their text,
+// dropping the comments holding nothing but drawings.
+func convertComments(comments []string) ([]string, []commonTypes.Drawing) {
+	texts := []string{}
+	drawings := []commonTypes.Drawing{}
+	for _, comment := range comments {
+		drawings = append(drawings, commonTypes.ParseDrawings(comment)...)
+		text := commonTypes.RemoveDrawingsCommands(comment)
+		if text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return texts, drawings
+}
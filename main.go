@@ -1,51 +1,51 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"image/color"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/storage"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 
-	"github.com/cloudfoundry-attic/jibber_jabber"
 	"github.com/gookit/ini/v2"
+	"github.com/loloof64/chess-pgn-reviser-fyne/analysis"
 	"github.com/loloof64/chess-pgn-reviser-fyne/chessboard"
 	"github.com/loloof64/chess-pgn-reviser-fyne/commonTypes"
+	"github.com/loloof64/chess-pgn-reviser-fyne/config/locales"
+	"github.com/loloof64/chess-pgn-reviser-fyne/engine"
+	"github.com/loloof64/chess-pgn-reviser-fyne/gamePicker"
 	"github.com/loloof64/chess-pgn-reviser-fyne/history"
 	"github.com/loloof64/chess-pgn-reviser-fyne/pgnLoader"
+	"github.com/loloof64/chess-pgn-reviser-fyne/positionEditor"
+	"github.com/loloof64/chess-pgn-reviser-fyne/preferences"
+	"github.com/loloof64/chess-pgn-reviser-fyne/progress"
+	"github.com/loloof64/chess-pgn-reviser-fyne/report"
+	"github.com/loloof64/chess-pgn-reviser-fyne/revision"
 	"github.com/notnil/chess"
 )
 
-func loadLocales() {
-	lang, err := jibber_jabber.DetectLanguage()
-	if err != nil {
-		lang = "en"
-	}
-
-	langFiles := map[string]string{
-		"en": "config/locales/en.ini",
-		"fr": "config/locales/fr.ini",
-		"es": "config/locales/es.ini",
-	}
-
-	langFileToLoad, found := langFiles[lang]
-	if !found {
-		langFileToLoad = langFiles["en"]
-	}
-
-	err = ini.LoadExists(langFileToLoad)
+// loadLocales loads the locale of the given language, or of the system
+// language if it is empty.
+func loadLocales(language string) {
+	err := locales.Load(language)
 	if err != nil {
 		panic(err)
 	}
 }
 
 func buildAppInstance() fyne.App {
-	app := app.New()
+	// An identifier is needed for the preferences to be stored.
+	app := app.NewWithID(preferences.AppID)
 	currentTheme := app.Settings().Theme()
 	if currentTheme == theme.LightTheme() {
 		app.Settings().SetTheme(&CustomLightTheme{})
@@ -57,16 +57,129 @@ func buildAppInstance() fyne.App {
 	return app
 }
 
-func buildMainWindow(app fyne.App) fyne.Window {
+func buildMainWindow(app fyne.App, userPreferences *preferences.Preferences) fyne.Window {
 	title := ini.String("general.title")
 	mainWindow := app.NewWindow(title)
+	if windowSize, stored := userPreferences.WindowSize(); stored {
+		mainWindow.Resize(windowSize)
+	}
 	return mainWindow
 }
 
-func buildMainContent(mainWindow fyne.Window) fyne.CanvasObject {
+// loadProgressStore loads the revision progress of the user, starting
+// with an empty one if it cannot be read.
+func loadProgressStore() *progress.Store {
+	storePath, err := progress.DefaultPath()
+	if err != nil {
+		fmt.Println(err)
+		return progress.NewStore("")
+	}
+
+	store, err := progress.Open(storePath)
+	if err != nil {
+		fmt.Println(err)
+		return progress.NewStore(storePath)
+	}
+	return store
+}
+
+// analysisOverlay is the name of the board overlay showing the best move of the analysis.
+const analysisOverlay = "analysis"
+
+// bestMoveArrowColor is the color of the arrow of the best move found by the analysis.
+var bestMoveArrowColor = color.NRGBA{20, 160, 60, 0xc0}
+
+// hintOverlay is the name of the board overlay showing the hints of the revision.
+const hintOverlay = "hint"
+
+var (
+	hintCellColor  = color.NRGBA{230, 200, 0, 0x90}
+	hintArrowColor = color.NRGBA{230, 160, 0, 0xc0}
+)
+
+// cellOf returns the cell of the given square.
+func cellOf(square chess.Square) commonTypes.Cell {
+	return commonTypes.Cell{File: int8(square.File()), Rank: int8(square.Rank())}
+}
+
+// startPreviewLength is the length of the board previewing the start of a revision.
+const startPreviewLength = 180
+
+// describeMainlineMove returns the given move with its number, as 1. e4 or 1... e5.
+func describeMainlineMove(move *pgnLoader.MoveNode) string {
+	fenParts := strings.Fields(move.Parent.Position.String())
+	moveNumber := fenParts[len(fenParts)-1]
+	if move.IsWhiteMove() {
+		return fmt.Sprintf("%s. %s", moveNumber, move.San)
+	}
+	return fmt.Sprintf("%s... %s", moveNumber, move.San)
+}
+
+// reportSearchTime is the time the engine searches each position of a blunder report.
+const reportSearchTime = 300 * time.Millisecond
+
+// buildBlunderReport evaluates the moves of the trained side with the engine
+// at the given path, launched for the occasion.
+func buildBlunderReport(ctx context.Context, enginePath string, session *revision.Session,
+	onProgress func(done int, total int)) ([]report.MoveReport, error) {
+	reportEngine, err := engine.Start(enginePath)
+	if err != nil {
+		return nil, err
+	}
+	defer reportEngine.Close()
+
+	return report.Build(ctx, reportEngine, session.Path(), session.TrainedSide(), reportSearchTime, onProgress)
+}
+
+// drillSearchTime is the time the engine searches each move of a drilled position.
+const drillSearchTime = 100 * time.Millisecond
+
+// findGoodMoves evaluates the legal moves of the given position with the
+// engine at the given path, launched for the occasion.
+func findGoodMoves(enginePath string, fen string) (map[string]bool, error) {
+	drillEngine, err := engine.Start(enginePath)
+	if err != nil {
+		return nil, err
+	}
+	defer drillEngine.Close()
+
+	return report.GoodMoves(context.Background(), drillEngine, fen, drillSearchTime)
+}
+
+// showMainContent builds the content of the main window, and builds it again
+// whenever the user chooses another language.
+func showMainContent(mainWindow fyne.Window, userPreferences *preferences.Preferences) {
+	var closeMainContent func()
+	var buildAndShow func()
+	buildAndShow = func() {
+		if closeMainContent != nil {
+			closeMainContent()
+		}
+
+		var mainContent fyne.CanvasObject
+		mainContent, closeMainContent = buildMainContent(mainWindow, userPreferences, func() {
+			loadLocales(userPreferences.Language())
+			mainWindow.SetTitle(ini.String("general.title"))
+			buildAndShow()
+		})
+		mainWindow.SetContent(mainContent)
+	}
+	buildAndShow()
+}
+
+// buildMainContent builds the content of the main window, with the loaded
+// locale, and sets the window menu. onLanguageChanged is called when the user
+// chooses another language. The returned function releases the content
+// resources, such as the analysis engine.
+func buildMainContent(mainWindow fyne.Window, userPreferences *preferences.Preferences,
+	onLanguageChanged func()) (fyne.CanvasObject, func()) {
+	progressStore := loadProgressStore()
 
-	boardOrientation := chessboard.BlackAtBottom
-	chessboardComponent := chessboard.NewChessBoard(400, &mainWindow)
+	chessboardComponent := chessboard.NewChessBoard(320, &mainWindow)
+	evaluationBar := chessboard.NewEvaluationBar(320)
+	// The clocks are only shown during the revisions having some.
+	clocks := chessboard.NewClocks()
+	clocks.Hide()
 	historyComponent := history.NewHistory(fyne.NewSize(400, 400))
 
 	gotoPreviousHistoryButton := widget.NewButtonWithIcon("", resourcePreviousSvg, func() {
@@ -85,6 +198,31 @@ func buildMainContent(mainWindow fyne.Window) fyne.CanvasObject {
 		historyComponent.RequestLastItemSelection()
 	})
 
+	enterVariationButton := widget.NewButtonWithIcon("", theme.MoveDownIcon(), func() {
+		historyComponent.RequestEnterVariation()
+	})
+
+	leaveVariationButton := widget.NewButtonWithIcon("", theme.MoveUpIcon(), func() {
+		historyComponent.RequestLeaveVariation()
+	})
+
+	// The comments of the moves are written in the exported games.
+	editCommentButton := widget.NewButtonWithIcon("", theme.MailComposeIcon(), func() {
+		commentEntry := widget.NewMultiLineEntry()
+		commentEntry.Wrapping = fyne.TextWrapWord
+		commentEntry.SetText(historyComponent.Comment())
+		commentDialog := dialog.NewForm(ini.String("moveComment.title"), ini.String("general.okButton"),
+			ini.String("general.cancelButton"),
+			[]*widget.FormItem{widget.NewFormItem(ini.String("moveComment.comment"), commentEntry)},
+			func(confirmed bool) {
+				if confirmed {
+					historyComponent.SetComment(commentEntry.Text)
+				}
+			}, mainWindow)
+		commentDialog.Resize(fyne.NewSize(400, 250))
+		commentDialog.Show()
+	})
+
 	historyButtonsZone := fyne.NewContainerWithLayout(
 		layout.NewCenterLayout(),
 		fyne.NewContainerWithLayout(
@@ -93,6 +231,9 @@ func buildMainContent(mainWindow fyne.Window) fyne.CanvasObject {
 			gotoPreviousHistoryButton,
 			gotoNextHistoryButton,
 			gotoLastHistoryButton,
+			enterVariationButton,
+			leaveVariationButton,
+			editCommentButton,
 		),
 	)
 
@@ -104,18 +245,452 @@ func buildMainContent(mainWindow fyne.Window) fyne.CanvasObject {
 		historyButtonsZone.Show()
 	}
 
+	analysisPanel := analysis.NewPanel(mainWindow)
+	analysisPanel.SetEnginePath(userPreferences.EnginePath())
+	analysisPanel.SetOnEnginePathChangedHandler(userPreferences.SetEnginePath)
+	analysisPanel.SetOnInfoHandler(func(info engine.Info, sideToMove chess.Color) {
+		evaluationBar.SetEvaluation(info.WhiteCentipawns(sideToMove))
+		if len(info.PvMoves) == 0 {
+			chessboardComponent.ClearOverlay(analysisOverlay)
+			return
+		}
+		bestMove := info.PvMoves[0]
+		chessboardComponent.SetOverlay(analysisOverlay, chessboard.Overlay{
+			Arrows: []chessboard.Arrow{{
+				Origin: cellOf(bestMove.S1()),
+				Target: cellOf(bestMove.S2()),
+				Color:  bestMoveArrowColor,
+			}},
+		})
+	})
+	analysisPanel.SetOnClearedHandler(func() {
+		evaluationBar.ClearEvaluation()
+		chessboardComponent.ClearOverlay(analysisOverlay)
+	})
+	analysisPanel.SetOnEvaluationBarToggledHandler(userPreferences.SetEvaluationBarShown)
+
+	// applyPreferences makes the preferences take effect, whenever they change.
+	applyPreferences := func() {
+		chessboardComponent.SetOrientation(userPreferences.BoardOrientation())
+		chessboardComponent.SetTheme(userPreferences.BoardTheme())
+		if chessboardComponent.DisplayMode() != userPreferences.DisplayMode() {
+			chessboardComponent.SetDisplayMode(userPreferences.DisplayMode())
+		}
+		evaluationBar.SetOrientation(userPreferences.BoardOrientation())
+		clocks.SetOrientation(userPreferences.BoardOrientation())
+		if userPreferences.EvaluationBarShown() {
+			evaluationBar.Show()
+		} else {
+			evaluationBar.Hide()
+		}
+		analysisPanel.SetEvaluationBarShown(userPreferences.EvaluationBarShown())
+	}
+	applyPreferences()
+	removePreferencesListener := userPreferences.AddChangeListener(applyPreferences)
+
+	mainWindow.SetCloseIntercept(func() {
+		userPreferences.SetWindowSize(mainWindow.Canvas().Size())
+		mainWindow.Close()
+	})
+	// The engine process must not outlive the window.
+	mainWindow.SetOnClosed(analysisPanel.Close)
+
 	historyMainContent := container.NewVScroll(historyComponent)
 	historyMainContent.Resize(fyne.NewSize(400, 400))
 	historyZone := fyne.NewContainerWithLayout(
-		layout.NewBorderLayout(historyButtonsZone, nil, nil, nil),
+		layout.NewBorderLayout(historyButtonsZone, analysisPanel, nil, nil),
 		historyButtonsZone,
+		analysisPanel,
 		historyMainContent,
 	)
 	hideHistoryNavigationToolbar()
 
+	var revisionSession *revision.Session
+	// drilledPosition is the bad position the user plays again, if any.
+	var drilledPosition *progress.BadPosition
+	// drillGoodMoves are the moves, in UCI notation, losing little in the
+	// drilled position, nil until the engine has evaluated them.
+	var drillGoodMoves map[string]bool
+
+	playOpponentMoveIfNeeded := func() {
+		session := revisionSession
+		if session == nil || session.Finished() || session.IsUserTurn() {
+			return
+		}
+		time.AfterFunc(userPreferences.OpponentMoveDelay(), func() {
+			// The move is played with the events of the window, where the
+			// session can be compared: the game may have been stopped or
+			// replaced in the meantime.
+			chessboard.QueueEvent(mainWindow, func() {
+				if session != revisionSession || session.Finished() || session.IsUserTurn() {
+					return
+				}
+				chessboardComponent.PlayMove(session.ExpectedMove())
+			})
+		})
+	}
+
+	// showBlunderReport compares the moves of the user with the engine evaluations
+	// in the background, records the bad positions, and shows the report.
+	showBlunderReport := func(session *revision.Session) {
+		enginePath := userPreferences.EnginePath()
+		if !userPreferences.BlunderReportEnabled() || enginePath == "" || len(session.Path()) == 0 {
+			return
+		}
+
+		reportContext, cancelReport := context.WithCancel(context.Background())
+
+		progressBar := widget.NewProgressBar()
+		progressDialog := dialog.NewCustom(ini.String("report.progressTitle"),
+			ini.String("general.cancelButton"), progressBar, mainWindow)
+		progressDialog.SetOnClosed(cancelReport)
+		progressDialog.Show()
+
+		// Only the engine works in the background: the progress store and the
+		// widgets are updated with the events of the window.
+		go func() {
+			moveReports, err := buildBlunderReport(reportContext, enginePath, session, func(done int, total int) {
+				chessboard.QueueEvent(mainWindow, func() {
+					progressBar.SetValue(float64(done) / float64(total))
+				})
+			})
+			chessboard.QueueEvent(mainWindow, func() {
+				progressDialog.Hide()
+
+				if err == context.Canceled {
+					return
+				}
+				if err != nil {
+					fmt.Println(err)
+					dialog.ShowInformation(ini.String("report.errorTitle"), ini.String("report.errorMessage"), mainWindow)
+					return
+				}
+
+				for _, moveReport := range moveReports {
+					if moveReport.Classification < report.Inaccuracy {
+						continue
+					}
+					badPosition := progress.BadPosition{
+						GameKey:        session.Game().Key,
+						Fen:            moveReport.Node.Parent.Position.String(),
+						PlayedMove:     moveReport.Node.Move.String(),
+						Classification: moveReport.Classification.Name(),
+						Recorded:       time.Now(),
+					}
+					if moveReport.BestMove != nil {
+						badPosition.BestMove = moveReport.BestMove.String()
+					}
+					progressStore.RecordBadPosition(badPosition)
+				}
+				err = progressStore.Save()
+				if err != nil {
+					fmt.Println(err)
+				}
+
+				report.ShowReport(moveReports, func(moveReport report.MoveReport) {
+					positionBeforeMove := commonTypes.GameMove{Fen: moveReport.Node.Parent.Position.String()}
+					if chessboardComponent.RequestHistoryPosition(positionBeforeMove) {
+						analysisPanel.Analyze(positionBeforeMove.Fen)
+					}
+				})
+			})
+		}()
+	}
+
+	// finishRevision ends the revision session once the board game has been
+	// stopped, recording the followed line, scheduling the next review of the
+	// game if the line has been completed, adding the variations of the
+	// revised game to the history, and showing the results of the user, with
+	// the given outcome of the game, and the bad moves.
+	finishRevision := func(outcome string) {
+		session := revisionSession
+		revisionSession = nil
+		showHistoryNavigationToolbar()
+		chessboardComponent.ClearOverlay(hintOverlay)
+		if session == nil {
+			return
+		}
+
+		// A session stopped before the user's first move is no attempt.
+		if len(session.Results()) > 0 {
+			progressStore.RecordLine(session.Game().Key, session.LinesCount(),
+				session.Line(), session.Finished(), session.Mistakes())
+			if session.Finished() {
+				// The hints weigh on the grade: a move played by a hint counts
+				// all the hints of its turn, plus one mistake.
+				progressStore.Review(session.Game().Key,
+					progress.QualityFromMistakes(session.Mistakes()+session.Hints()), time.Now())
+			}
+			err := progressStore.Save()
+			if err != nil {
+				fmt.Println(err)
+			}
+		}
+		historyComponent.MergeMovesTree(history.NewMovesTree(session.Game()))
+
+		pathFens := []string{}
+		// The plies of the results count the skipped moves.
+		for _, node := range append(session.SkippedMoves(), session.Path()...) {
+			pathFens = append(pathFens, node.Position.String())
+		}
+		revision.ShowResults(outcome, session.Results(), func(result revision.MoveResult) {
+			historyComponent.RequestPathSelection(pathFens[:result.Ply])
+		})
+		showBlunderReport(session)
+	}
+
+	// exportedGameTags are the tags of the last revised game, given to its export.
+	var exportedGameTags *pgnLoader.TagPairs
+
+	startRevision := func(session *revision.Session, timeControl chessboard.TimeControl) {
+		revisionSession = session
+		drilledPosition = nil
+		exportedGameTags = session.Game().Tags
+
+		hideHistoryNavigationToolbar()
+		analysisPanel.Stop()
+		// The moves skipped by the session are already played in the history.
+		historyComponent.Clear(revisionSession.StartPosition(), history.NewMovesLine(revisionSession.SkippedMoves())...)
+		chessboardComponent.SetTimeControl(timeControl)
+		if timeControl.Enabled() {
+			clocks.Show()
+		} else {
+			clocks.Hide()
+		}
+		chessboardComponent.NewGame(revisionSession.CurrentPosition())
+		chessboardComponent.SetUserSide(revisionSession.TrainedSide())
+		playOpponentMoveIfNeeded()
+	}
+
+	// startFreeGame lets the user play both sides from the given position,
+	// without any revision session checking the moves.
+	startFreeGame := func(fen string) {
+		revisionSession = nil
+		drilledPosition = nil
+		exportedGameTags = pgnLoader.NewTagPairs()
+
+		hideHistoryNavigationToolbar()
+		analysisPanel.Stop()
+		historyComponent.Clear(fen)
+		chessboardComponent.SetTimeControl(chessboard.TimeControl{})
+		clocks.Hide()
+		chessboardComponent.NewGame(fen)
+		chessboardComponent.SetUserSide(chess.NoColor)
+	}
+
+	// startDrill lets the user play again the side to move in a bad position,
+	// until the best move, or another move losing little, is found. The other
+	// moves are evaluated in the background, with the engine of the preferences.
+	startDrill := func(position progress.BadPosition) {
+		startFreeGame(position.Fen)
+		drilledPosition = &position
+		drillGoodMoves = nil
+		if enginePath := userPreferences.EnginePath(); enginePath != "" {
+			drill := drilledPosition
+			go func() {
+				goodMoves, err := findGoodMoves(enginePath, position.Fen)
+				if err != nil {
+					fmt.Println(err)
+					return
+				}
+				chessboard.QueueEvent(mainWindow, func() {
+					if drilledPosition == drill {
+						drillGoodMoves = goodMoves
+					}
+				})
+			}()
+		}
+		if strings.Fields(position.Fen)[1] == "b" {
+			chessboardComponent.SetUserSide(chess.Black)
+		} else {
+			chessboardComponent.SetUserSide(chess.White)
+		}
+	}
+
+	analyzePosition := func(fen string) {
+		historyComponent.Clear(fen)
+		historyComponent.RequestStartPositionSelection()
+	}
+
+	// showRevisionOptions lets the user choose the trained side, the clock, and
+	// the move of the main line of the game after which the revision starts.
+	showRevisionOptions := func(game *pgnLoader.Game,
+		onSelected func(trainedSide chess.Color, startPly int, timeControl chessboard.TimeControl)) {
+		whiteSide := ini.String("sideSelection.white")
+		blackSide := ini.String("sideSelection.black")
+
+		sideChoice := widget.NewRadioGroup([]string{whiteSide, blackSide}, nil)
+		sideChoice.Required = true
+		// Defaults to the side at the bottom of the board, unless the user
+		// has explicitly chosen another side before.
+		defaultSide := chess.White
+		if chessboardComponent.Orientation() == chessboard.BlackAtBottom {
+			defaultSide = chess.Black
+		}
+		if storedSide, stored := userPreferences.TrainedSide(); stored {
+			defaultSide = storedSide
+		}
+		if defaultSide == chess.Black {
+			sideChoice.SetSelected(blackSide)
+		} else {
+			sideChoice.SetSelected(whiteSide)
+		}
+		sideChanged := false
+		sideChoice.OnChanged = func(string) {
+			sideChanged = true
+		}
+
+		// The last move of the main line can't be skipped, as there would be nothing left to revise.
+		mainline := game.Mainline()
+		startMoves := history.NewMovesLine(mainline[:len(mainline)-1])
+		startPly := 0
+
+		preview := chessboard.NewChessBoard(startPreviewLength, &mainWindow)
+		preview.SetTheme(userPreferences.BoardTheme())
+		previewPly := func(ply int) {
+			if ply == 0 {
+				preview.RequestHistoryPosition(commonTypes.GameMove{Fen: game.Root.Position.String()})
+				return
+			}
+			preview.RequestHistoryPosition(startMoves[ply-1])
+		}
+		previewPly(startPly)
+
+		startPlyChoice := widget.NewList(
+			func() int {
+				return len(startMoves) + 1
+			},
+			func() fyne.CanvasObject {
+				return widget.NewLabel("")
+			},
+			func(itemIndex widget.ListItemID, item fyne.CanvasObject) {
+				if itemIndex == 0 {
+					item.(*widget.Label).SetText(ini.String("sideSelection.startPosition"))
+					return
+				}
+				item.(*widget.Label).SetText(describeMainlineMove(mainline[itemIndex-1]))
+			},
+		)
+		startPlyChoice.OnSelected = func(itemIndex widget.ListItemID) {
+			startPly = itemIndex
+			previewPly(startPly)
+		}
+		startPlyChoice.Select(startPly)
+
+		startPlyZone := container.NewBorder(widget.NewLabel(ini.String("sideSelection.startFrom")), nil, nil,
+			preview, startPlyChoice)
+		timeControlChoice, chosenTimeControl := newTimeControlChoice(userPreferences.TimeControl())
+		optionsContent := container.NewBorder(container.NewVBox(sideChoice, timeControlChoice), nil, nil, nil,
+			startPlyZone)
+
+		dialogTitle := ini.String("sideSelection.dialogTitle")
+		confirmButtonText := ini.String("general.okButton")
+		cancelButtonText := ini.String("general.cancelButton")
+
+		selectionDialog := dialog.NewCustomConfirm(dialogTitle, confirmButtonText,
+			cancelButtonText, optionsContent, func(confirmed bool) {
+				if !confirmed {
+					return
+				}
+				timeControl, err := chosenTimeControl()
+				if err != nil {
+					fmt.Println(err)
+					dialog.ShowInformation(dialogTitle, ini.String("sideSelection.invalidClock"), mainWindow)
+					return
+				}
+				trainedSide := chess.White
+				if sideChoice.Selected == blackSide {
+					trainedSide = chess.Black
+				}
+				if sideChanged {
+					userPreferences.SetTrainedSide(trainedSide)
+				}
+				userPreferences.SetTimeControl(timeControl)
+				onSelected(trainedSide, startPly, timeControl)
+			}, mainWindow)
+		selectionDialog.Resize(fyne.NewSize(500, 550))
+		selectionDialog.Show()
+	}
+
 	errorOpeningFileTitle := ini.String("serialization.errorOpeningFileTitle")
 	errorOpeningFileMessage := ini.String("serialization.errorOpeningFileMessage")
 
+	startSelectedGame := func(selectedGameParsed *pgnLoader.Game) {
+		if len(selectedGameParsed.Root.Children) == 0 {
+			dialog.ShowInformation(errorOpeningFileTitle, ini.String("revision.noMoves"), mainWindow)
+			return
+		}
+
+		showRevisionOptions(selectedGameParsed, func(trainedSide chess.Color, startPly int,
+			timeControl chessboard.TimeControl) {
+			coveredLines := progressStore.CoveredLines(selectedGameParsed.Key)
+			startRevision(revision.NewSession(selectedGameParsed, trainedSide, coveredLines, startPly), timeControl)
+		})
+	}
+
+	startIndexedGame := func(index *pgnLoader.Index, gameIndex int) {
+		selectedGameParsed, err := index.LoadGame(gameIndex)
+		if err != nil {
+			fmt.Println(err)
+			dialog.ShowInformation(errorOpeningFileTitle, errorOpeningFileMessage, mainWindow)
+			return
+		}
+
+		startSelectedGame(selectedGameParsed)
+	}
+
+	// openPgnIndex indexes the games of the given file in the background,
+	// showing the progress and letting the user cancel.
+	openPgnIndex := func(filePath string, onIndexReady func(index *pgnLoader.Index)) {
+		indexingContext, cancelIndexing := context.WithCancel(context.Background())
+
+		progressBar := widget.NewProgressBar()
+		progressDialog := dialog.NewCustom(ini.String("indexing.dialogTitle"),
+			ini.String("general.cancelButton"), progressBar, mainWindow)
+		progressDialog.SetOnClosed(cancelIndexing)
+		progressDialog.Show()
+
+		// The widgets and the progress store read by onIndexReady are only used
+		// with the events of the window.
+		go func() {
+			index, err := pgnLoader.OpenIndex(indexingContext, filePath, func(readBytes int64, totalBytes int64) {
+				if totalBytes > 0 {
+					chessboard.QueueEvent(mainWindow, func() {
+						progressBar.SetValue(float64(readBytes) / float64(totalBytes))
+					})
+				}
+			})
+			chessboard.QueueEvent(mainWindow, func() {
+				progressDialog.Hide()
+
+				if err == context.Canceled {
+					return
+				}
+				if err != nil {
+					fmt.Println(err)
+					dialog.ShowInformation(errorOpeningFileTitle, errorOpeningFileMessage, mainWindow)
+					return
+				}
+
+				onIndexReady(index)
+			})
+		}()
+	}
+
+	gamesSummaries := func(index *pgnLoader.Index) []gamePicker.GameSummary {
+		summaries := make([]gamePicker.GameSummary, len(index.Entries))
+		now := time.Now()
+		for entryIndex, entry := range index.Entries {
+			summaries[entryIndex].Tags = entry.Tags
+			summaries[entryIndex].Due = progressStore.IsDue(entry.Key, now)
+			coveredLines, totalLines := progressStore.Coverage(entry.Key)
+			if totalLines > 0 {
+				summaries[entryIndex].Coverage = fmt.Sprintf("%d/%d", coveredLines, totalLines)
+			}
+		}
+		return summaries
+	}
+
 	startGameItem := widget.NewToolbarAction(resourceStartSvg, func() {
 		openFileDialog := dialog.NewFileOpen(func(fileData fyne.URIReadCloser, err error) {
 			if err != nil {
@@ -131,43 +706,43 @@ func buildMainContent(mainWindow fyne.Window) fyne.CanvasObject {
 			fileNameRune := []rune(fmt.Sprintf("%v", fileData.URI()))
 			// Stripping "file://" prefix
 			filePath := string(fileNameRune[7:])
+			userPreferences.SetLastDirectory(filepath.Dir(filePath))
 
-			pgnLoader, err := pgnLoader.LoadPgnFile(filePath)
-
-			if err != nil {
-				fmt.Println(err)
-				dialog.ShowInformation(errorOpeningFileTitle, errorOpeningFileMessage, mainWindow)
-				return
-			}
-
-			selectedGamePgn := pgnLoader.Games[0]
-			reader := strings.NewReader(selectedGamePgn)
-			selectedGameUpdater, err := chess.PGN(reader)
-
-			if err != nil {
-				fmt.Println(err)
-				dialog.ShowInformation(errorOpeningFileTitle, errorOpeningFileMessage, mainWindow)
-				return
-			}
+			openPgnIndex(filePath, func(index *pgnLoader.Index) {
+				if len(index.Entries) == 0 {
+					dialog.ShowInformation(errorOpeningFileTitle, errorOpeningFileMessage, mainWindow)
+					return
+				}
 
-			selectedGameParsed := chess.NewGame()
-			selectedGameUpdater(selectedGameParsed)
-			selectedGameStartPosition := selectedGameParsed.Positions()[0].String()
+				if len(index.Entries) == 1 {
+					startIndexedGame(index, 0)
+					return
+				}
 
-			hideHistoryNavigationToolbar()
-			historyComponent.Clear(selectedGameStartPosition)
-			chessboardComponent.NewGame(selectedGameStartPosition)
+				gamePicker.ShowGamePicker(gamesSummaries(index), mainWindow, func(gameIndex int) {
+					startIndexedGame(index, gameIndex)
+				})
+			})
 		}, mainWindow)
+		if lastDirectory := userPreferences.LastDirectory(); lastDirectory != "" {
+			location, err := storage.ListerForURI(storage.NewFileURI(lastDirectory))
+			if err == nil {
+				openFileDialog.SetLocation(location)
+			}
+		}
 		openFileDialog.Show()
 	})
 
 	reverseBoardItem := widget.NewToolbarAction(resourceReverseSvg, func() {
-		if boardOrientation == chessboard.BlackAtBottom {
-			boardOrientation = chessboard.BlackAtTop
+		if chessboardComponent.Orientation() == chessboard.BlackAtBottom {
+			userPreferences.SetBoardOrientation(chessboard.BlackAtTop)
 		} else {
-			boardOrientation = chessboard.BlackAtBottom
+			userPreferences.SetBoardOrientation(chessboard.BlackAtBottom)
 		}
-		chessboardComponent.SetOrientation(boardOrientation)
+	})
+
+	settingsItem := widget.NewToolbarAction(theme.SettingsIcon(), func() {
+		preferences.ShowSettingsDialog(userPreferences, mainWindow)
 	})
 
 	stopGameItem := widget.NewToolbarAction(resourceStopSvg, func() {
@@ -186,13 +761,139 @@ func buildMainContent(mainWindow fyne.Window) fyne.CanvasObject {
 		confirmDialog := dialog.NewCustomConfirm(dialogTitle, confirmButtonText,
 			cancelButtonText, dialogComponent, func(confirmed bool) {
 				if confirmed {
-					showHistoryNavigationToolbar()
 					chessboardComponent.StopGame()
+					drilledPosition = nil
+					finishRevision(ini.String("results.stopped"))
 				}
 			}, mainWindow)
 		confirmDialog.Show()
 	})
 
+	// The hints reveal more of the expected move at each use: its origin
+	// cell, then its arrow, then the move itself is played.
+	hintItem := widget.NewToolbarAction(theme.HelpIcon(), func() {
+		if revisionSession == nil || !chessboardComponent.GameInProgress() {
+			return
+		}
+
+		hintLevel, expectedMove := revisionSession.NextHint()
+		switch hintLevel {
+		case revision.OriginCellHint:
+			chessboardComponent.SetOverlay(hintOverlay, chessboard.Overlay{
+				Highlights: []chessboard.Highlight{{Cell: cellOf(expectedMove.S1()), Color: hintCellColor}},
+			})
+		case revision.MoveArrowHint:
+			chessboardComponent.SetOverlay(hintOverlay, chessboard.Overlay{
+				Highlights: []chessboard.Highlight{{Cell: cellOf(expectedMove.S1()), Color: hintCellColor}},
+				Arrows: []chessboard.Arrow{{
+					Origin: cellOf(expectedMove.S1()),
+					Target: cellOf(expectedMove.S2()),
+					Color:  hintArrowColor,
+				}},
+			})
+		case revision.PlayedMoveHint:
+			chessboardComponent.PlayMove(expectedMove)
+		}
+	})
+
+	// A peek shows the pieces hidden by the display mode for a few seconds,
+	// which counts as a hint during a revision.
+	peekItem := widget.NewToolbarAction(theme.VisibilityIcon(), func() {
+		if chessboardComponent.DisplayMode() == chessboard.ShowAllPieces {
+			return
+		}
+		if revisionSession != nil && chessboardComponent.GameInProgress() && !revisionSession.Peek() {
+			return
+		}
+		chessboardComponent.Peek()
+	})
+
+	showExportError := func(err error) {
+		fmt.Println(err)
+		dialog.ShowInformation(ini.String("exportGame.errorTitle"), ini.String("exportGame.errorMessage"), mainWindow)
+	}
+
+	// showExportDialog lets the user save the given PGN game to a new file,
+	// append it to an existing file, or copy it to the clipboard.
+	showExportDialog := func(pgn string) {
+		var exportDialog dialog.Dialog
+
+		newFileButton := widget.NewButton(ini.String("exportGame.newFile"), func() {
+			exportDialog.Hide()
+			saveFileDialog := dialog.NewFileSave(func(fileData fyne.URIWriteCloser, err error) {
+				if err != nil {
+					showExportError(err)
+					return
+				}
+				if fileData == nil {
+					return
+				}
+				defer fileData.Close()
+
+				_, err = fileData.Write([]byte(pgn))
+				if err != nil {
+					showExportError(err)
+				}
+			}, mainWindow)
+			saveFileDialog.SetFileName("game.pgn")
+			saveFileDialog.Show()
+		})
+
+		appendButton := widget.NewButton(ini.String("exportGame.appendToFile"), func() {
+			exportDialog.Hide()
+			openFileDialog := dialog.NewFileOpen(func(fileData fyne.URIReadCloser, err error) {
+				if err != nil {
+					showExportError(err)
+					return
+				}
+				if fileData == nil {
+					return
+				}
+				_ = fileData.Close()
+
+				err = pgnLoader.AppendGame(fileData.URI().Path(), pgn)
+				if err != nil {
+					showExportError(err)
+				}
+			}, mainWindow)
+			openFileDialog.Show()
+		})
+
+		clipboardButton := widget.NewButton(ini.String("exportGame.clipboard"), func() {
+			exportDialog.Hide()
+			mainWindow.Clipboard().SetContent(pgn)
+		})
+
+		exportDialog = dialog.NewCustom(ini.String("exportGame.dialogTitle"), ini.String("general.cancelButton"),
+			container.NewVBox(newFileButton, appendButton, clipboardButton), mainWindow)
+		exportDialog.Show()
+	}
+
+	exportGameItem := widget.NewToolbarAction(theme.DocumentSaveIcon(), func() {
+		if chessboardComponent.GameInProgress() || exportedGameTags == nil {
+			dialog.ShowInformation(ini.String("exportGame.dialogTitle"), ini.String("exportGame.noGame"), mainWindow)
+			return
+		}
+
+		movesTree := historyComponent.MovesTree()
+		tags := pgnLoader.ExportTags(exportedGameTags, movesTree.Fen, chessboardComponent.EndStatus().PgnResult())
+		showExportDialog(pgnLoader.FormatGame(tags, movesTree))
+	})
+
+	badPositionsItem := widget.NewToolbarAction(theme.MediaReplayIcon(), func() {
+		if chessboardComponent.GameInProgress() {
+			return
+		}
+		report.ShowBadPositions(progressStore.BadPositionsList(), startDrill)
+	})
+
+	positionEditorItem := widget.NewToolbarAction(theme.DocumentCreateIcon(), func() {
+		if chessboardComponent.GameInProgress() {
+			return
+		}
+		positionEditor.ShowEditor(chessboardComponent.Fen(), chessboardComponent.Theme(), mainWindow, startFreeGame, analyzePosition)
+	})
+
 	gameFinished := ini.String("general.gameFinished")
 
 	whiteWon := ini.String("gameResult.whiteWon")
@@ -201,54 +902,156 @@ func buildMainContent(mainWindow fyne.Window) fyne.CanvasObject {
 
 	draw := ini.String("gameResult.draw")
 
+	revisionCompleted := ini.String("revision.completed")
+
+	// showGameOutcome shows the outcome of the game, with the results of the
+	// user in a revision game.
+	showGameOutcome := func(outcome string) {
+		if revisionSession == nil {
+			dialog.ShowInformation(gameFinished, outcome, mainWindow)
+		}
+		finishRevision(outcome)
+	}
+
 	chessboardComponent.SetOnWhiteWinHandler(func() {
-		showHistoryNavigationToolbar()
-		dialog.ShowInformation(gameFinished, whiteWon, mainWindow)
+		showGameOutcome(whiteWon)
 	})
 
 	chessboardComponent.SetOnBlackWinHandler(func() {
-		showHistoryNavigationToolbar()
-		dialog.ShowInformation(gameFinished, blackWon, mainWindow)
+		showGameOutcome(blackWon)
 	})
 
 	chessboardComponent.SetOnDrawHandler(func() {
-		showHistoryNavigationToolbar()
-		dialog.ShowInformation(gameFinished, draw, mainWindow)
+		showGameOutcome(draw)
+	})
+
+	chessboardComponent.SetOnMoveValidationHandler(func(move *chess.Move) bool {
+		// A drilled position expects its best move, or another move losing
+		// little once evaluated, or any other move than the bad one when
+		// neither the best move nor the evaluations are known.
+		if drilledPosition != nil {
+			switch {
+			case move.String() == drilledPosition.PlayedMove:
+				return false
+			case move.String() == drilledPosition.BestMove || drillGoodMoves[move.String()]:
+				return true
+			}
+			return drilledPosition.BestMove == "" && drillGoodMoves == nil
+		}
+		// Without revision session, the game has been started from the position editor.
+		return revisionSession == nil || revisionSession.CheckMove(move)
 	})
 
 	chessboardComponent.SetOnMoveDoneHandler(func(moveData commonTypes.GameMove) {
 		historyComponent.AddMove(moveData)
+		chessboardComponent.ClearOverlay(hintOverlay)
+		if drilledPosition != nil {
+			// The drilled position has been solved, so it is forgotten.
+			progressStore.RemoveBadPosition(*drilledPosition)
+			drilledPosition = nil
+			chessboardComponent.StopGame()
+			err := progressStore.Save()
+			if err != nil {
+				fmt.Println(err)
+			}
+			dialog.ShowInformation(ini.String("badPositions.windowTitle"), ini.String("badPositions.found"), mainWindow)
+			return
+		}
+		if revisionSession == nil {
+			return
+		}
+
+		revisionSession.Advance(moveData.Fen)
+		if revisionSession.Finished() {
+			chessboardComponent.StopGame()
+			finishRevision(revisionCompleted)
+			return
+		}
+
+		playOpponentMoveIfNeeded()
+	})
+
+	chessboardComponent.SetOnClockTickHandler(clocks.SetTimes)
+
+	// Once the time of the user has run out, the move counts as failed and is played for the user.
+	chessboardComponent.SetOnTimeOutHandler(func(side chess.Color) *chess.Move {
+		session := revisionSession
+		if session == nil || side != session.TrainedSide() {
+			return nil
+		}
+		return session.TimeOut()
+	})
+
+	// Without a time per move, a side out of time has lost: the board game has been stopped.
+	chessboardComponent.SetOnTimeLostHandler(func(side chess.Color) {
+		finishRevision(ini.String("results.timeLost"))
 	})
 
 	chessboardComponent.SetOnRequestLastHistoryPositionHandler(func() {
 		historyComponent.RequestLastItemSelection()
 	})
 
+	chessboardComponent.SetOnDrawingsChangedHandler(func(drawings []commonTypes.Drawing) {
+		historyComponent.SetDrawings(drawings)
+	})
+
 	historyComponent.SetOnPositionRequestHandler(
 		func(moveData commonTypes.GameMove) bool {
-			return chessboardComponent.RequestHistoryPosition(moveData)
+			accepted := chessboardComponent.RequestHistoryPosition(moveData)
+			if accepted {
+				analysisPanel.Analyze(moveData.Fen)
+			}
+			return accepted
 		})
 
-	toolbar := widget.NewToolbar(startGameItem, reverseBoardItem, stopGameItem)
+	toolbar := widget.NewToolbar(startGameItem, positionEditorItem, badPositionsItem, reverseBoardItem, stopGameItem, hintItem, peekItem, exportGameItem, settingsItem)
 
-	gameZone := fyne.NewContainerWithLayout(layout.NewHBoxLayout(),
-		chessboardComponent, historyZone)
+	moveEntryComponent := chessboard.NewMoveEntry(chessboardComponent)
+	boardZone := container.NewBorder(nil, moveEntryComponent, evaluationBar, clocks, chessboardComponent)
 
-	mainLayout := layout.NewVBoxLayout()
-	mainContent := fyne.NewContainerWithLayout(
-		mainLayout,
-		toolbar,
-		gameZone,
-	)
+	gameZone := fyne.NewContainerWithLayout(newGameLayout(chessboardComponent),
+		boardZone, historyZone)
+
+	mainContent := container.NewBorder(toolbar, nil, nil, nil, gameZone)
+
+	changeLanguage := func(language string) {
+		// Building the content again would lose the game in progress.
+		if chessboardComponent.GameInProgress() {
+			dialog.ShowInformation(ini.String("languageMenu.title"), ini.String("languageMenu.gameInProgress"), mainWindow)
+			return
+		}
+		userPreferences.SetLanguage(language)
+		onLanguageChanged()
+	}
+
+	systemLanguageItem := fyne.NewMenuItem(ini.String("languageMenu.system"), func() {
+		changeLanguage("")
+	})
+	systemLanguageItem.Checked = userPreferences.Language() == ""
+	languageItems := []*fyne.MenuItem{systemLanguageItem}
+	for _, language := range locales.Languages {
+		languageCode := language.Code
+		languageItem := fyne.NewMenuItem(language.Name, func() {
+			changeLanguage(languageCode)
+		})
+		languageItem.Checked = userPreferences.Language() == languageCode
+		languageItems = append(languageItems, languageItem)
+	}
+	mainWindow.SetMainMenu(fyne.NewMainMenu(fyne.NewMenu(ini.String("languageMenu.title"), languageItems...)))
+
+	closeMainContent := func() {
+		removePreferencesListener()
+		analysisPanel.Close()
+	}
 
-	return mainContent
+	return mainContent, closeMainContent
 }
 
 func main() {
-	loadLocales()
 	app := buildAppInstance()
-	mainWindow := buildMainWindow(app)
-	mainContent := buildMainContent(mainWindow)
-	mainWindow.SetContent(mainContent)
+	userPreferences := preferences.New(app.Preferences())
+	loadLocales(userPreferences.Language())
+	mainWindow := buildMainWindow(app, userPreferences)
+	showMainContent(mainWindow, userPreferences)
 	mainWindow.ShowAndRun()
 }
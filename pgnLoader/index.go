@@ -0,0 +1,298 @@
+package pgnLoader
+
+import (
+	"context"
+	"encoding/gob"
+	"io"
+	"os"
+)
+
+// indexCacheVersion must be increased whenever the cache format or the
+// scanning rules change, so that old caches are rebuilt.
+const indexCacheVersion = 3
+
+// indexCacheSuffix is appended to the PGN file path to get the index cache path.
+const indexCacheSuffix = ".idx"
+
+// progressStep is the number of bytes read between two progress reports.
+const progressStep = 1 << 20
+
+// GameEntry locates a game inside a PGN file.
+type GameEntry struct {
+	// Offset and Length delimit the game in the file, in bytes.
+	Offset int64
+	Length int64
+
+	// Line and Column locate the game start, starting at 1.
+	Line   int
+	Column int
+
+	Tags *TagPairs
+
+	// Key is the key of the game, as in Game.
+	Key string
+}
+
+// Index locates all the games of a PGN file, so that they can be loaded on demand.
+type Index struct {
+	Path    string
+	Entries []GameEntry
+}
+
+type indexCache struct {
+	Version          int
+	FileSize         int64
+	FileModification int64
+	Entries          []cachedGameEntry
+}
+
+type cachedGameEntry struct {
+	Offset int64
+	Length int64
+	Line   int
+	Column int
+	Tags   [][2]string
+	Key    string
+}
+
+// OpenIndex returns the index of the given PGN file, from its cache if it is
+// still valid, otherwise by scanning the file and saving a new cache.
+// While scanning, onProgress, if not nil, receives the count of bytes read
+// and the file size. Scanning stops with the context error if ctx is cancelled.
+func OpenIndex(ctx context.Context, path string, onProgress func(readBytes int64, totalBytes int64)) (*Index, error) {
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	index := loadIndexCache(path, fileInfo)
+	if index != nil {
+		return index, nil
+	}
+
+	index, err = BuildIndex(ctx, path, onProgress)
+	if err != nil {
+		return nil, err
+	}
+
+	// The cache is only an optimization : the directory may well be read-only.
+	_ = saveIndexCache(index, fileInfo)
+
+	return index, nil
+}
+
+// BuildIndex scans the given PGN file to locate its games and read their tag pairs.
+// While scanning, onProgress, if not nil, receives the count of bytes read
+// and the file size. Scanning stops with the context error if ctx is cancelled.
+func BuildIndex(ctx context.Context, path string, onProgress func(readBytes int64, totalBytes int64)) (*Index, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	totalBytes := fileInfo.Size()
+
+	lexer := newLexer(file)
+	index := &Index{Path: path}
+	var currentEntry *GameEntry
+	var keyHasher *gameKeyHasher
+	inMovetext := false
+	nextProgressReport := int64(0)
+
+	closeCurrentEntry := func(endOffset int64) {
+		if currentEntry == nil {
+			return
+		}
+		currentEntry.Length = endOffset - currentEntry.Offset
+		currentEntry.Key = keyHasher.key(currentEntry.Tags)
+		index.Entries = append(index.Entries, *currentEntry)
+		currentEntry = nil
+	}
+
+	for {
+		if lexer.offset >= nextProgressReport {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if onProgress != nil {
+				onProgress(lexer.offset, totalBytes)
+			}
+			nextProgressReport = lexer.offset + progressStep
+		}
+
+		nextToken, err := lexer.nextToken()
+		if _, isParseError := err.(*ParseError); isParseError {
+			// Reported when the game is loaded.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		startsNewGame := currentEntry == nil || (inMovetext && nextToken.kind == tagOpenToken)
+		if startsNewGame && nextToken.kind != endOfFileToken {
+			closeCurrentEntry(nextToken.offset)
+			currentEntry = &GameEntry{
+				Offset: nextToken.offset,
+				Line:   nextToken.line,
+				Column: nextToken.column,
+				Tags:   NewTagPairs(),
+			}
+			keyHasher = newGameKeyHasher()
+			inMovetext = false
+		}
+
+		switch {
+		case nextToken.kind == endOfFileToken:
+			closeCurrentEntry(nextToken.offset)
+			if onProgress != nil {
+				onProgress(totalBytes, totalBytes)
+			}
+			return index, nil
+		case nextToken.kind == tagOpenToken && !inMovetext:
+			scanTagPair(lexer, currentEntry.Tags)
+		case nextToken.kind == symbolToken && isResult(nextToken.value):
+			closeCurrentEntry(lexer.offset)
+		default:
+			keyHasher.addToken(nextToken)
+			inMovetext = true
+		}
+	}
+}
+
+// scanTagPair reads a tag pair whose opening bracket has just been read,
+// ignoring it if it is malformed.
+func scanTagPair(lexer *lexer, tags *TagPairs) {
+	name, err := lexer.nextToken()
+	if err != nil || name.kind != symbolToken {
+		return
+	}
+	value, err := lexer.nextToken()
+	if err != nil || value.kind != stringToken {
+		return
+	}
+	closing, err := lexer.nextToken()
+	if err != nil || closing.kind != tagCloseToken {
+		return
+	}
+	tags.Set(name.value, value.value)
+}
+
+// LoadGame reads and parses the game at the given index.
+func (index *Index) LoadGame(gameIndex int) (*Game, error) {
+	entry := index.Entries[gameIndex]
+
+	file, err := os.Open(index.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	_, err = file.Seek(entry.Offset, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	lexer := newLexerAt(io.LimitReader(file, entry.Length), entry.Offset, entry.Line, entry.Column)
+	game, err := newParser(lexer).parseGame()
+	if err != nil {
+		if parseError, isParseError := err.(*ParseError); isParseError {
+			parseError.File = index.Path
+			parseError.GameIndex = gameIndex
+		}
+		return nil, err
+	}
+
+	if game == nil {
+		return nil, &ParseError{File: index.Path, GameIndex: gameIndex,
+			Line: entry.Line, Column: entry.Column, Message: "missing game"}
+	}
+
+	parseError := game.resolve()
+	if parseError != nil {
+		parseError.File = index.Path
+		parseError.GameIndex = gameIndex
+		return nil, parseError
+	}
+
+	return game, nil
+}
+
+func loadIndexCache(path string, fileInfo os.FileInfo) *Index {
+	cacheFile, err := os.Open(path + indexCacheSuffix)
+	if err != nil {
+		return nil
+	}
+	defer cacheFile.Close()
+
+	var cache indexCache
+	err = gob.NewDecoder(cacheFile).Decode(&cache)
+	if err != nil {
+		return nil
+	}
+
+	cacheIsValid := cache.Version == indexCacheVersion &&
+		cache.FileSize == fileInfo.Size() &&
+		cache.FileModification == fileInfo.ModTime().UnixNano()
+	if !cacheIsValid {
+		return nil
+	}
+
+	index := &Index{Path: path, Entries: make([]GameEntry, len(cache.Entries))}
+	for entryIndex, cachedEntry := range cache.Entries {
+		tags := NewTagPairs()
+		for _, tag := range cachedEntry.Tags {
+			tags.Set(tag[0], tag[1])
+		}
+		index.Entries[entryIndex] = GameEntry{
+			Offset: cachedEntry.Offset,
+			Length: cachedEntry.Length,
+			Line:   cachedEntry.Line,
+			Column: cachedEntry.Column,
+			Tags:   tags,
+			Key:    cachedEntry.Key,
+		}
+	}
+
+	return index
+}
+
+func saveIndexCache(index *Index, fileInfo os.FileInfo) error {
+	cache := indexCache{
+		Version:          indexCacheVersion,
+		FileSize:         fileInfo.Size(),
+		FileModification: fileInfo.ModTime().UnixNano(),
+		Entries:          make([]cachedGameEntry, len(index.Entries)),
+	}
+	for entryIndex, entry := range index.Entries {
+		tags := [][2]string{}
+		for _, key := range entry.Tags.Keys() {
+			tags = append(tags, [2]string{key, entry.Tags.Get(key)})
+		}
+		cache.Entries[entryIndex] = cachedGameEntry{
+			Offset: entry.Offset,
+			Length: entry.Length,
+			Line:   entry.Line,
+			Column: entry.Column,
+			Tags:   tags,
+			Key:    entry.Key,
+		}
+	}
+
+	cacheFile, err := os.Create(index.Path + indexCacheSuffix)
+	if err != nil {
+		return err
+	}
+
+	err = gob.NewEncoder(cacheFile).Encode(cache)
+	closeErr := cacheFile.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
@@ -0,0 +1,61 @@
+package pgnLoader
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenIndexRestoresTheEntriesFromItsCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "games.pgn")
+	content := "[White \"First\"]\n\n1. e4 e5 *\n\n[White \"Second\"]\n[Black \"Other\"]\n\n1. d4 (1. c4) d5 1-0\n"
+	err := ioutil.WriteFile(path, []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	builtIndex, err := OpenIndex(context.Background(), path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = os.Stat(path + indexCacheSuffix)
+	if err != nil {
+		t.Fatalf("the index cache has not been saved: %v", err)
+	}
+
+	cachedIndex, err := OpenIndex(context.Background(), path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(builtIndex.Entries) != 2 || len(cachedIndex.Entries) != len(builtIndex.Entries) {
+		t.Fatalf("got %d entries from the cache, and %d from the file, expected 2",
+			len(cachedIndex.Entries), len(builtIndex.Entries))
+	}
+	for entryIndex, builtEntry := range builtIndex.Entries {
+		cachedEntry := cachedIndex.Entries[entryIndex]
+		if builtEntry.Key == "" || cachedEntry.Key != builtEntry.Key {
+			t.Errorf("game %d: got the key %q from the cache, and %q from the file",
+				entryIndex, cachedEntry.Key, builtEntry.Key)
+		}
+		if cachedEntry.Offset != builtEntry.Offset || cachedEntry.Length != builtEntry.Length ||
+			cachedEntry.Line != builtEntry.Line || cachedEntry.Column != builtEntry.Column {
+			t.Errorf("game %d: got the location %+v from the cache, and %+v from the file",
+				entryIndex, cachedEntry, builtEntry)
+		}
+		if cachedEntry.Tags.Get("White") != builtEntry.Tags.Get("White") {
+			t.Errorf("game %d: got White %q from the cache, and %q from the file",
+				entryIndex, cachedEntry.Tags.Get("White"), builtEntry.Tags.Get("White"))
+		}
+
+		game, err := cachedIndex.LoadGame(entryIndex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if game.Key != cachedEntry.Key {
+			t.Errorf("game %d: got the key %q once loaded, and %q in the index", entryIndex, game.Key, cachedEntry.Key)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package pgnLoader
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"hash"
+	"io"
+	"strings"
+
+	"github.com/loloof64/chess-pgn-reviser-fyne/commonTypes"
+)
+
+// gameKeyHasher computes the key of a game from its movetext tokens, keeping only
+// the moves and the variations structure, so that editing comments, annotations
+// or tags other than FEN does not change it.
+type gameKeyHasher struct {
+	hash hash.Hash
+}
+
+func newGameKeyHasher() *gameKeyHasher {
+	return &gameKeyHasher{hash: sha1.New()}
+}
+
+func (hasher *gameKeyHasher) addToken(movetextToken token) {
+	switch movetextToken.kind {
+	case symbolToken:
+		if isMoveNumber(movetextToken.value) || isResult(movetextToken.value) {
+			return
+		}
+		move := strings.TrimRight(commonTypes.NormalizeCastling(movetextToken.value), "+#")
+		_, _ = io.WriteString(hasher.hash, move+" ")
+	case variationOpenToken:
+		_, _ = io.WriteString(hasher.hash, "( ")
+	case variationCloseToken:
+		_, _ = io.WriteString(hasher.hash, ") ")
+	}
+}
+
+func (hasher *gameKeyHasher) key(tags *TagPairs) string {
+	if fen, found := tags.Lookup("FEN"); found {
+		_, _ = io.WriteString(hasher.hash, "FEN "+fen)
+	}
+	return hex.EncodeToString(hasher.hash.Sum(nil))
+}
@@ -0,0 +1,280 @@
+package pgnLoader
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	endOfFileToken tokenKind = iota
+	symbolToken
+	stringToken
+	periodToken
+	nagToken
+	commentToken
+	tagOpenToken
+	tagCloseToken
+	variationOpenToken
+	variationCloseToken
+)
+
+// byteOrderMark may start files saved by some editors.
+const byteOrderMark = '\uFEFF'
+
+// suffixAnnotations maps the traditional move suffix annotations to their NAG.
+var suffixAnnotations = map[string]int{
+	"!":  1,
+	"?":  2,
+	"!!": 3,
+	"??": 4,
+	"!?": 5,
+	"?!": 6,
+}
+
+type token struct {
+	kind  tokenKind
+	value string
+
+	// nag is the value of a nagToken.
+	nag int
+
+	// line and column are 1-based, offset is the byte offset of the token start.
+	line   int
+	column int
+	offset int64
+}
+
+// lexer splits PGN content into tokens, keeping track of their location.
+type lexer struct {
+	reader *bufio.Reader
+
+	line   int
+	column int
+	offset int64
+
+	// previousColumn is needed to restore the column when unreading a newline.
+	previousColumn int
+	lastRuneSize   int
+}
+
+func newLexer(reader io.Reader) *lexer {
+	return &lexer{reader: bufio.NewReader(reader), line: 1, column: 0}
+}
+
+// newLexerAt creates a lexer for content starting at the given location of a file.
+func newLexerAt(reader io.Reader, offset int64, line int, column int) *lexer {
+	return &lexer{reader: bufio.NewReader(reader), line: line, column: column - 1, offset: offset}
+}
+
+func (lexer *lexer) readRune() (rune, error) {
+	character, size, err := lexer.reader.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+
+	lexer.offset += int64(size)
+	lexer.lastRuneSize = size
+	lexer.previousColumn = lexer.column
+	if character == '\n' {
+		lexer.line++
+		lexer.column = 0
+	} else {
+		lexer.column++
+	}
+
+	return character, nil
+}
+
+func (lexer *lexer) unreadRune(character rune) {
+	if lexer.reader.UnreadRune() != nil {
+		return
+	}
+	lexer.offset -= int64(lexer.lastRuneSize)
+	if character == '\n' {
+		lexer.line--
+	}
+	lexer.column = lexer.previousColumn
+}
+
+func (lexer *lexer) errorAt(line int, column int, format string, args ...interface{}) *ParseError {
+	return &ParseError{Line: line, Column: column, Message: fmt.Sprintf(format, args...)}
+}
+
+// nextToken reads the next token, skipping spaces and escaped lines.
+func (lexer *lexer) nextToken() (token, error) {
+	for {
+		character, err := lexer.readRune()
+		if err == io.EOF {
+			return token{kind: endOfFileToken, line: lexer.line, column: lexer.column + 1, offset: lexer.offset}, nil
+		}
+		if err != nil {
+			return token{}, err
+		}
+
+		startLine, startColumn := lexer.line, lexer.column
+		startOffset := lexer.offset - int64(lexer.lastRuneSize)
+		newToken := func(kind tokenKind, value string) token {
+			return token{kind: kind, value: value, line: startLine, column: startColumn, offset: startOffset}
+		}
+
+		switch {
+		case unicode.IsSpace(character) || character == byteOrderMark:
+			continue
+		case character == '%' && startColumn == 1:
+			if err := lexer.skipLine(); err != nil {
+				return token{}, err
+			}
+			continue
+		case character == '[':
+			return newToken(tagOpenToken, "["), nil
+		case character == ']':
+			return newToken(tagCloseToken, "]"), nil
+		case character == '(':
+			return newToken(variationOpenToken, "("), nil
+		case character == ')':
+			return newToken(variationCloseToken, ")"), nil
+		case character == '.':
+			return newToken(periodToken, "."), nil
+		case character == '*':
+			return newToken(symbolToken, "*"), nil
+		case character == '{':
+			comment, err := lexer.readUntil('}')
+			if err == io.EOF {
+				return token{}, lexer.errorAt(startLine, startColumn, "unterminated comment")
+			}
+			if err != nil {
+				return token{}, err
+			}
+			return newToken(commentToken, strings.TrimSpace(comment)), nil
+		case character == ';':
+			comment, err := lexer.readUntil('\n')
+			if err != nil && err != io.EOF {
+				return token{}, err
+			}
+			return newToken(commentToken, strings.TrimSpace(comment)), nil
+		case character == '"':
+			value, err := lexer.readString()
+			if err == io.EOF {
+				return token{}, lexer.errorAt(startLine, startColumn, "unterminated string")
+			}
+			if err != nil {
+				return token{}, err
+			}
+			return newToken(stringToken, value), nil
+		case character == '$':
+			digits, err := lexer.readWhile(unicode.IsDigit)
+			if err != nil {
+				return token{}, err
+			}
+			if digits == "" {
+				return token{}, lexer.errorAt(startLine, startColumn, "missing NAG value")
+			}
+			nagValue := newToken(nagToken, "$"+digits)
+			nagValue.nag, err = strconv.Atoi(digits)
+			if err != nil {
+				return token{}, lexer.errorAt(startLine, startColumn, "bad NAG value %s", digits)
+			}
+			return nagValue, nil
+		case character == '!' || character == '?':
+			suffix, err := lexer.readWhile(func(next rune) bool { return next == '!' || next == '?' })
+			if err != nil {
+				return token{}, err
+			}
+			suffix = string(character) + suffix
+			nag, known := suffixAnnotations[suffix]
+			if !known {
+				return token{}, lexer.errorAt(startLine, startColumn, "unknown move annotation %s", suffix)
+			}
+			suffixValue := newToken(nagToken, suffix)
+			suffixValue.nag = nag
+			return suffixValue, nil
+		case isSymbolStart(character):
+			continuation, err := lexer.readWhile(isSymbolContinuation)
+			if err != nil {
+				return token{}, err
+			}
+			return newToken(symbolToken, string(character)+continuation), nil
+		default:
+			return token{}, lexer.errorAt(startLine, startColumn, "unexpected character %q", character)
+		}
+	}
+}
+
+func isSymbolStart(character rune) bool {
+	return character < unicode.MaxASCII && (unicode.IsLetter(character) || unicode.IsDigit(character))
+}
+
+func isSymbolContinuation(character rune) bool {
+	return isSymbolStart(character) || strings.ContainsRune("_+#=:-/", character)
+}
+
+// readWhile reads the runes matching the predicate, leaving the first non matching one unread.
+func (lexer *lexer) readWhile(predicate func(rune) bool) (string, error) {
+	var builder strings.Builder
+	for {
+		character, err := lexer.readRune()
+		if err == io.EOF {
+			return builder.String(), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if !predicate(character) {
+			lexer.unreadRune(character)
+			return builder.String(), nil
+		}
+		builder.WriteRune(character)
+	}
+}
+
+// readUntil reads the runes up to the given delimiter, which is consumed but not returned.
+func (lexer *lexer) readUntil(delimiter rune) (string, error) {
+	var builder strings.Builder
+	for {
+		character, err := lexer.readRune()
+		if err != nil {
+			return builder.String(), err
+		}
+		if character == delimiter {
+			return builder.String(), nil
+		}
+		builder.WriteRune(character)
+	}
+}
+
+// readString reads a string up to the closing quote, handling the escaped quotes and backslashes.
+func (lexer *lexer) readString() (string, error) {
+	var builder strings.Builder
+	for {
+		character, err := lexer.readRune()
+		if err != nil {
+			return "", err
+		}
+		switch character {
+		case '"':
+			return builder.String(), nil
+		case '\\':
+			escaped, err := lexer.readRune()
+			if err != nil {
+				return "", err
+			}
+			builder.WriteRune(escaped)
+		default:
+			builder.WriteRune(character)
+		}
+	}
+}
+
+func (lexer *lexer) skipLine() error {
+	_, err := lexer.readUntil('\n')
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
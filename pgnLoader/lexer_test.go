@@ -0,0 +1,76 @@
+package pgnLoader
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLexerSplitsTokens(t *testing.T) {
+	tests := []struct {
+		input  string
+		tokens []token
+	}{
+		{
+			input: `[Site "A \"B\""]`,
+			tokens: []token{
+				{kind: tagOpenToken, value: "[", line: 1, column: 1},
+				{kind: symbolToken, value: "Site", line: 1, column: 2},
+				{kind: stringToken, value: `A "B"`, line: 1, column: 7},
+				{kind: tagCloseToken, value: "]", line: 1, column: 16},
+			},
+		},
+		{
+			input: "12. exd8=Q+ $3\n  0-1",
+			tokens: []token{
+				{kind: symbolToken, value: "12", line: 1, column: 1},
+				{kind: periodToken, value: ".", line: 1, column: 3},
+				{kind: symbolToken, value: "exd8=Q+", line: 1, column: 5},
+				{kind: nagToken, value: "$3", nag: 3, line: 1, column: 13},
+				{kind: symbolToken, value: "0-1", line: 2, column: 3},
+			},
+		},
+		{
+			input: "(Nf3?! { a\nplan }) ; rest\n*",
+			tokens: []token{
+				{kind: variationOpenToken, value: "(", line: 1, column: 1},
+				{kind: symbolToken, value: "Nf3", line: 1, column: 2},
+				{kind: nagToken, value: "?!", nag: 6, line: 1, column: 5},
+				{kind: commentToken, value: "a\nplan", line: 1, column: 8},
+				{kind: variationCloseToken, value: ")", line: 2, column: 7},
+				{kind: commentToken, value: "rest", line: 2, column: 9},
+				{kind: symbolToken, value: "*", line: 3, column: 1},
+			},
+		},
+		{
+			input: "% escaped line\ne4",
+			tokens: []token{
+				{kind: symbolToken, value: "e4", line: 2, column: 1},
+			},
+		},
+		{
+			input: "\ufeffe4",
+			tokens: []token{
+				{kind: symbolToken, value: "e4", line: 1, column: 2},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		lexer := newLexer(strings.NewReader(test.input))
+		for _, expected := range test.tokens {
+			nextToken, err := lexer.nextToken()
+			if err != nil {
+				t.Errorf("%q: %v", test.input, err)
+				break
+			}
+			nextToken.offset = 0
+			if nextToken != expected {
+				t.Errorf("%q: got the token %+v, expected %+v", test.input, nextToken, expected)
+			}
+		}
+		lastToken, err := lexer.nextToken()
+		if err != nil || lastToken.kind != endOfFileToken {
+			t.Errorf("%q: got %+v and %v instead of the end of file", test.input, lastToken, err)
+		}
+	}
+}
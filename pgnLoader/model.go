@@ -0,0 +1,145 @@
+package pgnLoader
+
+import (
+	"fmt"
+
+	"github.com/notnil/chess"
+)
+
+// TagPairs holds the tag pairs of a game, keeping their order.
+type TagPairs struct {
+	keys   []string
+	values map[string]string
+}
+
+// NewTagPairs creates an empty set of tag pairs.
+func NewTagPairs() *TagPairs {
+	return &TagPairs{values: map[string]string{}}
+}
+
+// Get returns the value of the given tag, or an empty string if it is not defined.
+func (tags *TagPairs) Get(key string) string {
+	return tags.values[key]
+}
+
+// Lookup returns the value of the given tag, and whether it is defined.
+func (tags *TagPairs) Lookup(key string) (string, bool) {
+	value, found := tags.values[key]
+	return value, found
+}
+
+// Set defines the value of the given tag. A new tag is added after the existing ones.
+func (tags *TagPairs) Set(key string, value string) {
+	if _, found := tags.values[key]; !found {
+		tags.keys = append(tags.keys, key)
+	}
+	tags.values[key] = value
+}
+
+// Delete removes the given tag.
+func (tags *TagPairs) Delete(key string) {
+	if _, found := tags.values[key]; !found {
+		return
+	}
+	delete(tags.values, key)
+	for index, currentKey := range tags.keys {
+		if currentKey == key {
+			tags.keys = append(tags.keys[:index], tags.keys[index+1:]...)
+			break
+		}
+	}
+}
+
+// Keys returns the defined tags, in order.
+func (tags *TagPairs) Keys() []string {
+	return append([]string(nil), tags.keys...)
+}
+
+// MoveNode is a node of the moves tree of a game.
+type MoveNode struct {
+	// San is the move in Standard Algebraic Notation. It is empty for the root node.
+	San string
+
+	// Move is the decoded move. It is nil for the root node.
+	Move *chess.Move
+
+	// Position is the position after the move, or the start position for the root node.
+	Position *chess.Position
+
+	// Nags are the Numeric Annotation Glyphs of the move.
+	Nags []int
+
+	// PreComments are the comments written before the move, at the start of a variation.
+	PreComments []string
+
+	// Comments are the comments written after the move. For the root node,
+	// these are the comments written before the first move.
+	Comments []string
+
+	// Parent is the previous move, nil for the root node.
+	Parent *MoveNode
+
+	// Children are the next moves: the first one continues the current line,
+	// the others start variations.
+	Children []*MoveNode
+
+	// Line and Column locate the move in the file, starting at 1.
+	Line   int
+	Column int
+}
+
+// MainChild returns the move continuing the current line, or nil at the end of the line.
+func (node *MoveNode) MainChild() *MoveNode {
+	if len(node.Children) == 0 {
+		return nil
+	}
+	return node.Children[0]
+}
+
+// IsWhiteMove says whether the move has been played by White.
+func (node *MoveNode) IsWhiteMove() bool {
+	return node.Position.Turn() == chess.Black
+}
+
+// Game is a parsed PGN game.
+type Game struct {
+	Tags *TagPairs
+
+	// Root holds the start position, its children being the first moves of the game.
+	Root *MoveNode
+
+	// Result is the game termination marker: 1-0, 0-1, 1/2-1/2 or *.
+	Result string
+
+	// Key identifies the game by its moves, and stays the same when other
+	// games of the file are edited.
+	Key string
+}
+
+// Mainline returns the moves of the main line, in order.
+func (game *Game) Mainline() []*MoveNode {
+	var moves []*MoveNode
+	for node := game.Root.MainChild(); node != nil; node = node.MainChild() {
+		moves = append(moves, node)
+	}
+	return moves
+}
+
+// ParseError locates an error found while loading a PGN file.
+type ParseError struct {
+	File string
+
+	// GameIndex is the index of the game in the file, starting at 0.
+	GameIndex int
+
+	// Line and Column locate the error in the file, starting at 1.
+	Line   int
+	Column int
+
+	Message string
+}
+
+func (err *ParseError) Error() string {
+	return fmt.Sprintf("%s: game %d, line %d, column %d: %s",
+		err.File, err.GameIndex+1, err.Line, err.Column, err.Message)
+}
@@ -0,0 +1,273 @@
+package pgnLoader
+
+import (
+	"fmt"
+
+	"github.com/notnil/chess"
+
+	"github.com/loloof64/chess-pgn-reviser-fyne/commonTypes"
+)
+
+// parser builds games from the tokens of a lexer.
+type parser struct {
+	lexer  *lexer
+	peeked *token
+}
+
+func newParser(lexer *lexer) *parser {
+	return &parser{lexer: lexer}
+}
+
+func (parser *parser) next() (token, error) {
+	if parser.peeked != nil {
+		nextToken := *parser.peeked
+		parser.peeked = nil
+		return nextToken, nil
+	}
+	return parser.lexer.nextToken()
+}
+
+func (parser *parser) unread(previousToken token) {
+	parser.peeked = &previousToken
+}
+
+func (parser *parser) peek() (token, error) {
+	nextToken, err := parser.next()
+	if err != nil {
+		return token{}, err
+	}
+	parser.unread(nextToken)
+	return nextToken, nil
+}
+
+func unexpectedToken(unexpected token) *ParseError {
+	if unexpected.kind == endOfFileToken {
+		return &ParseError{Line: unexpected.line, Column: unexpected.column, Message: "unexpected end of file"}
+	}
+	return &ParseError{Line: unexpected.line, Column: unexpected.column,
+		Message: fmt.Sprintf("unexpected %s", unexpected.value)}
+}
+
+// parseGame parses the next game, returning nil at the end of the content.
+// The moves are not decoded yet.
+func (parser *parser) parseGame() (*Game, error) {
+	firstToken, err := parser.peek()
+	if err != nil {
+		return nil, err
+	}
+	if firstToken.kind == endOfFileToken {
+		return nil, nil
+	}
+
+	game := &Game{Tags: NewTagPairs(), Root: &MoveNode{Line: firstToken.line, Column: firstToken.column}}
+
+	err = parser.parseTagPairs(game.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	err = parser.parseMovetext(game)
+	if err != nil {
+		return nil, err
+	}
+
+	return game, nil
+}
+
+// resolve sets the start position of the game, and decodes all its moves.
+func (game *Game) resolve() *ParseError {
+	var err error
+	game.Root.Position, err = startPosition(game.Tags)
+	if err != nil {
+		return &ParseError{Line: game.Root.Line, Column: game.Root.Column, Message: err.Error()}
+	}
+
+	return resolveMoves(game.Root)
+}
+
+func (parser *parser) parseTagPairs(tags *TagPairs) error {
+	for {
+		nextToken, err := parser.next()
+		if err != nil {
+			return err
+		}
+		if nextToken.kind != tagOpenToken {
+			parser.unread(nextToken)
+			return nil
+		}
+
+		name, err := parser.next()
+		if err != nil {
+			return err
+		}
+		if name.kind != symbolToken {
+			return unexpectedToken(name)
+		}
+
+		value, err := parser.next()
+		if err != nil {
+			return err
+		}
+		if value.kind != stringToken {
+			return unexpectedToken(value)
+		}
+
+		closing, err := parser.next()
+		if err != nil {
+			return err
+		}
+		if closing.kind != tagCloseToken {
+			return unexpectedToken(closing)
+		}
+
+		tags.Set(name.value, value.value)
+	}
+}
+
+func (parser *parser) parseMovetext(game *Game) error {
+	currentNode := game.Root
+	// variationsParents are the nodes to go back to when closing the opened variations.
+	variationsParents := []*MoveNode{}
+	atVariationStart := false
+	pendingComments := []string{}
+	keyHasher := newGameKeyHasher()
+
+	for {
+		nextToken, err := parser.next()
+		if err != nil {
+			return err
+		}
+		keyHasher.addToken(nextToken)
+
+		switch nextToken.kind {
+		case endOfFileToken, tagOpenToken:
+			// Lenient about the missing game termination marker.
+			if len(variationsParents) > 0 {
+				return &ParseError{Line: nextToken.line, Column: nextToken.column, Message: "unterminated variation"}
+			}
+			parser.unread(nextToken)
+			game.Result = "*"
+			game.Key = keyHasher.key(game.Tags)
+			return nil
+		case symbolToken:
+			if isResult(nextToken.value) {
+				if len(variationsParents) > 0 {
+					return &ParseError{Line: nextToken.line, Column: nextToken.column, Message: "unterminated variation"}
+				}
+				game.Result = nextToken.value
+				game.Key = keyHasher.key(game.Tags)
+				return nil
+			}
+			if isMoveNumber(nextToken.value) {
+				continue
+			}
+			node := &MoveNode{
+				San:         commonTypes.NormalizeCastling(nextToken.value),
+				Parent:      currentNode,
+				PreComments: pendingComments,
+				Line:        nextToken.line,
+				Column:      nextToken.column,
+			}
+			pendingComments = []string{}
+			atVariationStart = false
+			currentNode.Children = append(currentNode.Children, node)
+			currentNode = node
+		case periodToken:
+			continue
+		case nagToken:
+			if currentNode == game.Root || atVariationStart {
+				return unexpectedToken(nextToken)
+			}
+			currentNode.Nags = append(currentNode.Nags, nextToken.nag)
+		case commentToken:
+			if atVariationStart {
+				pendingComments = append(pendingComments, nextToken.value)
+			} else {
+				currentNode.Comments = append(currentNode.Comments, nextToken.value)
+			}
+		case variationOpenToken:
+			if currentNode == game.Root || atVariationStart {
+				return unexpectedToken(nextToken)
+			}
+			variationsParents = append(variationsParents, currentNode)
+			currentNode = currentNode.Parent
+			atVariationStart = true
+		case variationCloseToken:
+			if len(variationsParents) == 0 || atVariationStart {
+				return unexpectedToken(nextToken)
+			}
+			currentNode = variationsParents[len(variationsParents)-1]
+			variationsParents = variationsParents[:len(variationsParents)-1]
+		default:
+			return unexpectedToken(nextToken)
+		}
+	}
+}
+
+func isResult(symbol string) bool {
+	return symbol == "1-0" || symbol == "0-1" || symbol == "1/2-1/2" || symbol == "*"
+}
+
+func isMoveNumber(symbol string) bool {
+	for _, character := range symbol {
+		if character < '0' || character > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func startPosition(tags *TagPairs) (*chess.Position, error) {
+	fen, found := tags.Lookup("FEN")
+	if !found {
+		return chess.StartingPosition(), nil
+	}
+
+	fenUpdater, err := chess.FEN(fen)
+	if err != nil {
+		return nil, fmt.Errorf("bad FEN tag %s", fen)
+	}
+	return chess.NewGame(fenUpdater).Position(), nil
+}
+
+// resolveMoves decodes the moves of the tree below the given root, whose position must be set.
+func resolveMoves(root *MoveNode) *ParseError {
+	pendingNodes := []*MoveNode{root}
+
+	for len(pendingNodes) > 0 {
+		node := pendingNodes[len(pendingNodes)-1]
+		pendingNodes = pendingNodes[:len(pendingNodes)-1]
+
+		for _, child := range node.Children {
+			move := decodeMove(node.Position, child.San)
+			if move == nil {
+				return &ParseError{Line: child.Line, Column: child.Column,
+					Message: fmt.Sprintf("illegal move %s", child.San)}
+			}
+			child.Move = move
+			child.Position = node.Position.Update(move)
+			pendingNodes = append(pendingNodes, child)
+		}
+	}
+
+	return nil
+}
+
+// decodeMove returns the legal move matching the given notation, or nil.
+func decodeMove(position *chess.Position, notation string) *chess.Move {
+	decoders := []chess.Decoder{chess.AlgebraicNotation{}, chess.LongAlgebraicNotation{}, chess.UCINotation{}}
+
+	for _, decoder := range decoders {
+		move, err := decoder.Decode(position, notation)
+		if err != nil {
+			continue
+		}
+		for _, validMove := range position.ValidMoves() {
+			if validMove.String() == move.String() {
+				return validMove
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,258 @@
+package pgnLoader
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadGameBuildsTheMovesTree(t *testing.T) {
+	tests := []struct {
+		name   string
+		pgn    string
+		tree   string
+		result string
+	}{
+		{
+			name:   "main line",
+			pgn:    "[Event \"Test\"]\n\n1. e4 e5 2. Nf3 Nc6 1-0",
+			tree:   "e4 e5 Nf3 Nc6",
+			result: "1-0",
+		},
+		{
+			name:   "missing termination marker",
+			pgn:    "1. d4 d5",
+			tree:   "d4 d5",
+			result: "*",
+		},
+		{
+			name:   "variations",
+			pgn:    "1. e4 e5 (1... c5 2. Nf3 (2. c3) d6) 2. Nf3 *",
+			tree:   "e4 e5 (c5 Nf3 (c3) d6) Nf3",
+			result: "*",
+		},
+		{
+			name:   "move numbers without spaces",
+			pgn:    "1.e4 e5 2.Nf3 1/2-1/2",
+			tree:   "e4 e5 Nf3",
+			result: "1/2-1/2",
+		},
+		{
+			name:   "castling written with zeros",
+			pgn:    "1. e4 e5 2. Nf3 Nc6 3. Bc4 Bc5 4. 0-0 *",
+			tree:   "e4 e5 Nf3 Nc6 Bc4 Bc5 O-O",
+			result: "*",
+		},
+		{
+			name:   "numeric and suffix annotations",
+			pgn:    "1. e4! $14 e5?? 2. Nf3!? *",
+			tree:   "e4$1$14 e5$4 Nf3$5",
+			result: "*",
+		},
+		{
+			name:   "comments",
+			pgn:    "{Before} 1. e4 {Best by test} e5 ({Also} 1... c5 ; Sicilian\n) *",
+			tree:   "{Before} e4 {Best by test} e5 ({Also} c5 {Sicilian})",
+			result: "*",
+		},
+		{
+			name:   "escaped lines",
+			pgn:    "% exported by a tool\n1. e4 e5 *",
+			tree:   "e4 e5",
+			result: "*",
+		},
+	}
+
+	for _, test := range tests {
+		games, parseErrors := loadGames(t, test.pgn)
+		if len(parseErrors) > 0 || len(games) != 1 {
+			t.Errorf("%s: got %d games and the errors %v", test.name, len(games), parseErrors)
+			continue
+		}
+		if tree := describeTree(games[0].Root); tree != test.tree {
+			t.Errorf("%s: got the tree %q, expected %q", test.name, tree, test.tree)
+		}
+		if games[0].Result != test.result {
+			t.Errorf("%s: got the result %q, expected %q", test.name, games[0].Result, test.result)
+		}
+	}
+}
+
+func TestLoadGameReadsEscapedTagValues(t *testing.T) {
+	tests := []struct {
+		pgn   string
+		value string
+	}{
+		{pgn: `[White "Plain"] *`, value: "Plain"},
+		{pgn: `[White "The \"Best\""] *`, value: `The "Best"`},
+		{pgn: `[White "Back\\slash"] *`, value: `Back\slash`},
+		{pgn: `[White ""] *`, value: ""},
+	}
+
+	for _, test := range tests {
+		games, parseErrors := loadGames(t, test.pgn)
+		if len(parseErrors) > 0 || len(games) != 1 {
+			t.Errorf("%s: got %d games and the errors %v", test.pgn, len(games), parseErrors)
+			continue
+		}
+		if value := games[0].Tags.Get("White"); value != test.value {
+			t.Errorf("%s: got %q, expected %q", test.pgn, value, test.value)
+		}
+	}
+}
+
+func TestLoadGameIsolatesTheGamesWithErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		pgn       string
+		games     int
+		gameIndex int
+		line      int
+		message   string
+	}{
+		{
+			name:    "illegal move",
+			pgn:     "1. e4 e5 2. Ke3 *\n\n1. d4 *",
+			games:   1,
+			line:    1,
+			message: "illegal move Ke3",
+		},
+		{
+			name:      "illegal move between two games",
+			pgn:       "[Event \"First\"]\n\n1. e4 *\n\n[Event \"Bad\"]\n\n1. e4 e5\n2. Ke3 *\n\n[Event \"Last\"]\n\n1. d4 *",
+			games:     2,
+			gameIndex: 1,
+			line:      8,
+			message:   "illegal move Ke3",
+		},
+		{
+			name:    "unterminated variation",
+			pgn:     "1. e4 (1. d4 *",
+			games:   0,
+			line:    1,
+			message: "unterminated variation",
+		},
+		{
+			name:    "unopened variation",
+			pgn:     "1. e4 ) e5 *\n\n[Event \"Next\"]\n1. c4 *",
+			games:   1,
+			line:    1,
+			message: "unexpected )",
+		},
+		{
+			name:    "unterminated comment",
+			pgn:     "1. e4 {never closed",
+			games:   0,
+			line:    1,
+			message: "unterminated comment",
+		},
+		{
+			name:    "variation before any move",
+			pgn:     "(1. e4) 1. d4 *",
+			games:   0,
+			line:    1,
+			message: "unexpected (",
+		},
+		{
+			name:    "unknown suffix annotation",
+			pgn:     "1. e4 e5\n2. Nf3!!! *",
+			games:   0,
+			line:    2,
+			message: "unknown move annotation !!!",
+		},
+	}
+
+	for _, test := range tests {
+		games, parseErrors := loadGames(t, test.pgn)
+		if len(games) != test.games {
+			t.Errorf("%s: got %d games, expected %d", test.name, len(games), test.games)
+		}
+		if len(parseErrors) != 1 {
+			t.Errorf("%s: got the errors %v, expected one", test.name, parseErrors)
+			continue
+		}
+		parseError, isParseError := parseErrors[0].(*ParseError)
+		if !isParseError {
+			t.Errorf("%s: got the error %v, expected a ParseError", test.name, parseErrors[0])
+			continue
+		}
+		if parseError.GameIndex != test.gameIndex || parseError.Line != test.line ||
+			parseError.Message != test.message || filepath.Base(parseError.File) != "test.pgn" {
+			t.Errorf("%s: got the error %q in the game %d at line %d, expected %q in the game %d at line %d",
+				test.name, parseError.Message, parseError.GameIndex, parseError.Line,
+				test.message, test.gameIndex, test.line)
+		}
+	}
+}
+
+// loadGames indexes the given content, written to a file, and loads each of
+// its games, returning the loaded games and the errors of the others.
+func loadGames(t *testing.T, content string) ([]*Game, []error) {
+	path := filepath.Join(t.TempDir(), "test.pgn")
+	err := ioutil.WriteFile(path, []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := BuildIndex(context.Background(), path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	games := []*Game{}
+	loadErrors := []error{}
+	for gameIndex := range index.Entries {
+		game, err := index.LoadGame(gameIndex)
+		if err != nil {
+			loadErrors = append(loadErrors, err)
+			continue
+		}
+		games = append(games, game)
+	}
+	return games, loadErrors
+}
+
+// describeTree writes the moves below the given node as in a PGN movetext,
+// without move numbers, and with the annotations glued to their move.
+func describeTree(node *MoveNode) string {
+	tokens := []string{}
+	for _, comment := range node.Comments {
+		tokens = append(tokens, "{"+comment+"}")
+	}
+	for ; len(node.Children) > 0; node = node.Children[0] {
+		tokens = append(tokens, describeMove(node.Children[0]))
+		for _, variation := range node.Children[1:] {
+			tokens = append(tokens, "("+strings.Join(append([]string{describeMove(variation)},
+				describeLine(variation)...), " ")+")")
+		}
+	}
+	return strings.Join(tokens, " ")
+}
+
+// describeLine describes the moves following the given one.
+func describeLine(node *MoveNode) []string {
+	line := describeTree(&MoveNode{Children: node.Children})
+	if line == "" {
+		return nil
+	}
+	return []string{line}
+}
+
+func describeMove(node *MoveNode) string {
+	tokens := []string{}
+	for _, comment := range node.PreComments {
+		tokens = append(tokens, "{"+comment+"}")
+	}
+	move := node.San
+	for _, nag := range node.Nags {
+		move += fmt.Sprintf("$%d", nag)
+	}
+	tokens = append(tokens, move)
+	for _, comment := range node.Comments {
+		tokens = append(tokens, "{"+comment+"}")
+	}
+	return strings.Join(tokens, " ")
+}
@@ -0,0 +1,246 @@
+package pgnLoader
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/notnil/chess"
+
+	"github.com/loloof64/chess-pgn-reviser-fyne/commonTypes"
+)
+
+// maxMovetextLineLength is the length the movetext lines are wrapped at.
+const maxMovetextLineLength = 80
+
+// sevenTagRoster are the tags required in every PGN game, in their order.
+var sevenTagRoster = []string{"Event", "Site", "Date", "Round", "White", "Black", "Result"}
+
+// sevenTagRosterDefaults are the values of the missing tags of the roster.
+var sevenTagRosterDefaults = map[string]string{
+	"Event":  "?",
+	"Site":   "?",
+	"Date":   "????.??.??",
+	"Round":  "?",
+	"White":  "?",
+	"Black":  "?",
+	"Result": "*",
+}
+
+// ExportTags returns the Seven Tag Roster of the given tags, the missing ones
+// being unknown, with the given result. SetUp and FEN tags are added when the
+// start position, in Forsyth-Edwards Notation, is not the standard one.
+func ExportTags(source *TagPairs, startFen string, result string) *TagPairs {
+	tags := NewTagPairs()
+	for _, key := range sevenTagRoster {
+		value, found := source.Lookup(key)
+		if !found || value == "" {
+			value = sevenTagRosterDefaults[key]
+		}
+		tags.Set(key, value)
+	}
+	tags.Set("Result", result)
+
+	if startFen != chess.StartingPosition().String() {
+		tags.Set("SetUp", "1")
+		tags.Set("FEN", startFen)
+	}
+
+	return tags
+}
+
+// FormatGame writes a game in PGN: its tags, then the moves tree starting at
+// the given root, variations, NAGs, comments and drawings included, then the
+// result given by the Result tag.
+func FormatGame(tags *TagPairs, root *commonTypes.GameMove) string {
+	var builder strings.Builder
+	for _, key := range tags.Keys() {
+		fmt.Fprintf(&builder, "[%s \"%s\"]\n", key, escapeTagValue(tags.Get(key)))
+	}
+	builder.WriteString("\n")
+
+	writer := &movetextWriter{needsMoveNumber: true}
+	writer.writeAnnotations(root)
+	writer.writeLine(root)
+	result := tags.Get("Result")
+	if result == "" {
+		result = "*"
+	}
+	writer.tokens = append(writer.tokens, result)
+
+	builder.WriteString(wrapTokens(writer.tokens, maxMovetextLineLength))
+	builder.WriteString("\n")
+	return builder.String()
+}
+
+// AppendGame adds a game, as written by FormatGame, at the end of the PGN
+// file at the given path, creating it if needed. The game is separated from
+// the previous ones by an empty line, so that they are left untouched.
+func AppendGame(path string, pgn string) error {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	separator := ""
+	if fileInfo.Size() > 0 {
+		lastBytesCount := int64(2)
+		if fileInfo.Size() < lastBytesCount {
+			lastBytesCount = fileInfo.Size()
+		}
+		lastBytes := make([]byte, lastBytesCount)
+		_, err = file.ReadAt(lastBytes, fileInfo.Size()-lastBytesCount)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case strings.HasSuffix(string(lastBytes), "\n\n"):
+		case strings.HasSuffix(string(lastBytes), "\n"):
+			separator = "\n"
+		default:
+			separator = "\n\n"
+		}
+	}
+
+	_, err = file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	_, err = file.WriteString(separator + pgn)
+	return err
+}
+
+// movetextWriter builds the tokens of a movetext.
+type movetextWriter struct {
+	tokens []string
+
+	// needsMoveNumber says whether the next black move needs a move number,
+	// as after a comment or a variation.
+	needsMoveNumber bool
+}
+
+// writeLine writes the moves following the given node, along the first
+// children, the other children being written as variations.
+func (writer *movetextWriter) writeLine(node *commonTypes.GameMove) {
+	for len(node.Children) > 0 {
+		mainChild := node.Children[0]
+		writer.writeMove(mainChild)
+
+		for _, variationStart := range node.Children[1:] {
+			writer.tokens = append(writer.tokens, "(")
+			writer.needsMoveNumber = true
+			writer.writeMove(variationStart)
+			writer.writeLine(variationStart)
+			writer.tokens = append(writer.tokens, ")")
+			writer.needsMoveNumber = true
+		}
+
+		node = mainChild
+	}
+}
+
+func (writer *movetextWriter) writeMove(node *commonTypes.GameMove) {
+	moveNumber := 1
+	if node.Parent != nil {
+		moveNumber = fullMoveNumberOf(node.Parent.Fen)
+	}
+
+	if !node.IsBlackMove {
+		writer.tokens = append(writer.tokens, fmt.Sprintf("%d.", moveNumber))
+	} else if writer.needsMoveNumber {
+		writer.tokens = append(writer.tokens, fmt.Sprintf("%d...", moveNumber))
+	}
+	writer.tokens = append(writer.tokens, node.San)
+	for _, nag := range node.Nags {
+		writer.tokens = append(writer.tokens, fmt.Sprintf("$%d", nag))
+	}
+	writer.needsMoveNumber = false
+
+	writer.writeAnnotations(node)
+}
+
+// writeAnnotations writes the comments and drawings of the node as a single comment.
+func (writer *movetextWriter) writeAnnotations(node *commonTypes.GameMove) {
+	parts := []string{}
+	for _, comment := range node.Comments {
+		// A PGN comment cannot hold a closing brace, which would end it
+		// early: it is removed rather than replaced by another character.
+		if comment = strings.TrimSpace(strings.ReplaceAll(comment, "}", "")); comment != "" {
+			parts = append(parts, comment)
+		}
+	}
+	if drawings := commonTypes.FormatDrawings(node.Drawings); drawings != "" {
+		parts = append(parts, drawings)
+	}
+	if len(parts) == 0 {
+		return
+	}
+
+	writer.tokens = append(writer.tokens, "{"+strings.Join(parts, " ")+"}")
+	writer.needsMoveNumber = true
+}
+
+func fullMoveNumberOf(fen string) int {
+	fenParts := strings.Fields(fen)
+	moveNumber, err := strconv.Atoi(fenParts[len(fenParts)-1])
+	if err != nil {
+		return 1
+	}
+	return moveNumber
+}
+
+// wrapTokens joins the tokens with spaces, without space inside the brackets
+// of the variations, going to a new line before exceeding the given length.
+// The comments may go to a new line between their words.
+func wrapTokens(tokens []string, maxLength int) string {
+	var builder strings.Builder
+	lineLength := 0
+	addWord := func(word string, needsSpace bool) {
+		needsSpace = needsSpace && lineLength > 0
+		addedLength := len(word)
+		if needsSpace {
+			addedLength++
+		}
+
+		if lineLength > 0 && lineLength+addedLength > maxLength {
+			builder.WriteString("\n")
+			lineLength = 0
+			needsSpace = false
+			addedLength = len(word)
+		}
+		if needsSpace {
+			builder.WriteString(" ")
+		}
+		builder.WriteString(word)
+		lineLength += addedLength
+	}
+
+	previousToken := ""
+	for _, token := range tokens {
+		needsSpace := previousToken != "(" && token != ")"
+		if strings.HasPrefix(token, "{") {
+			for _, word := range strings.Fields(token) {
+				addWord(word, needsSpace)
+				needsSpace = true
+			}
+		} else {
+			addWord(token, needsSpace)
+		}
+		previousToken = token
+	}
+	return builder.String()
+}
+
+func escapeTagValue(value string) string {
+	value = strings.ReplaceAll(value, "\\", "\\\\")
+	return strings.ReplaceAll(value, "\"", "\\\"")
+}
@@ -0,0 +1,204 @@
+package pgnLoader
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/notnil/chess"
+
+	"github.com/loloof64/chess-pgn-reviser-fyne/commonTypes"
+)
+
+// addLine adds the given moves, in Standard Algebraic Notation, after the
+// given node, returning the last added one.
+func addLine(t *testing.T, node *commonTypes.GameMove, sans ...string) *commonTypes.GameMove {
+	for _, san := range sans {
+		fen, err := chess.FEN(node.Fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		position := chess.NewGame(fen).Position()
+		move, err := chess.AlgebraicNotation{}.Decode(position, san)
+		if err != nil {
+			t.Fatalf("%s: %v", san, err)
+		}
+		child := &commonTypes.GameMove{
+			San:         san,
+			Fen:         position.Update(move).String(),
+			IsBlackMove: position.Turn() == chess.Black,
+			Parent:      node,
+		}
+		node.Children = append(node.Children, child)
+		node = child
+	}
+	return node
+}
+
+func TestFormatGameCanBeParsedBack(t *testing.T) {
+	annotatedRoot := func() *commonTypes.GameMove {
+		root := &commonTypes.GameMove{Fen: chess.StartingPosition().String(), Comments: []string{"Before"}}
+		e4 := addLine(t, root, "e4")
+		e4.Nags = []int{1, 14}
+		e4.Comments = []string{"Best by test"}
+		e5 := addLine(t, e4, "e5")
+		e5.Drawings = []commonTypes.Drawing{{Origin: commonTypes.Cell{File: 4, Rank: 4},
+			Target: commonTypes.Cell{File: 4, Rank: 4}, Color: commonTypes.RedDrawing}}
+		addLine(t, e5, "Nf3", "Nc6")
+		c5 := addLine(t, e4, "c5")
+		c5.Comments = []string{"Sicilian"}
+		addLine(t, c5, "Nf3")
+		return root
+	}
+
+	tests := []struct {
+		name   string
+		root   *commonTypes.GameMove
+		result string
+
+		tree string
+	}{
+		{
+			name:   "annotated tree",
+			root:   annotatedRoot(),
+			result: "1-0",
+			tree:   "{Before} e4$1$14 {Best by test} e5 {[%csl Re5]} (c5 {Sicilian} Nf3) Nf3 Nc6",
+		},
+		{
+			name: "black to move from a position",
+			root: func() *commonTypes.GameMove {
+				root := &commonTypes.GameMove{Fen: "4k3/8/8/8/8/8/4P3/4K3 b - - 0 40"}
+				addLine(t, root, "Kd7", "e4", "Kc6")
+				return root
+			}(),
+			result: "1/2-1/2",
+			tree:   "Kd7 e4 Kc6",
+		},
+		{
+			name: "closing brace in a comment",
+			root: func() *commonTypes.GameMove {
+				root := &commonTypes.GameMove{Fen: chess.StartingPosition().String()}
+				addLine(t, root, "d4").Comments = []string{"Closed } here", "}"}
+				addLine(t, root.Children[0], "d5")
+				return root
+			}(),
+			result: "*",
+			tree:   "d4 {Closed here} d5",
+		},
+		{
+			name: "long line",
+			root: func() *commonTypes.GameMove {
+				root := &commonTypes.GameMove{Fen: chess.StartingPosition().String()}
+				addLine(t, root, "Nf3", "Nf6", "Ng1", "Ng8", "Nf3", "Nf6", "Ng1", "Ng8", "Nc3", "Nc6",
+					"Nb1", "Nb8", "Nc3", "Nc6", "Nb1", "Nb8").Comments = []string{strings.Repeat("long ", 30)}
+				return root
+			}(),
+			result: "0-1",
+			tree: "Nf3 Nf6 Ng1 Ng8 Nf3 Nf6 Ng1 Ng8 Nc3 Nc6 Nb1 Nb8 Nc3 Nc6 Nb1 Nb8 {" +
+				strings.TrimSpace(strings.Repeat("long ", 30)) + "}",
+		},
+	}
+
+	source := NewTagPairs()
+	source.Set("Event", "Club match")
+	source.Set("White", "Someone")
+	source.Set("Annotator", "Dropped")
+
+	for _, test := range tests {
+		tags := ExportTags(source, test.root.Fen, test.result)
+		pgn := FormatGame(tags, test.root)
+		for _, line := range strings.Split(pgn, "\n") {
+			if len(line) > maxMovetextLineLength {
+				t.Errorf("%s: the line %q is longer than %d", test.name, line, maxMovetextLineLength)
+			}
+		}
+
+		games, parseErrors := loadGames(t, pgn)
+		if len(parseErrors) > 0 || len(games) != 1 {
+			t.Fatalf("%s: got %d games and the errors %v from:\n%s", test.name, len(games), parseErrors, pgn)
+		}
+		game := games[0]
+
+		// The comments may be wrapped, their spaces and line ends being alike.
+		if tree := strings.Join(strings.Fields(describeTree(game.Root)), " "); tree != test.tree {
+			t.Errorf("%s: got the tree %q, expected %q", test.name, tree, test.tree)
+		}
+		if game.Result != test.result || game.Tags.Get("Result") != test.result {
+			t.Errorf("%s: got the result %q and the Result tag %q, expected %q", test.name,
+				game.Result, game.Tags.Get("Result"), test.result)
+		}
+		if game.Root.Position.String() != test.root.Fen {
+			t.Errorf("%s: got the start position %s, expected %s", test.name, game.Root.Position, test.root.Fen)
+		}
+
+		expectedTags := []string{"Event", "Site", "Date", "Round", "White", "Black", "Result"}
+		if test.root.Fen != chess.StartingPosition().String() {
+			expectedTags = append(expectedTags, "SetUp", "FEN")
+		}
+		if keys := game.Tags.Keys(); strings.Join(keys, " ") != strings.Join(expectedTags, " ") {
+			t.Errorf("%s: got the tags %v, expected %v", test.name, keys, expectedTags)
+		}
+		if game.Tags.Get("Event") != "Club match" || game.Tags.Get("Black") != "?" || game.Tags.Get("Date") != "????.??.??" {
+			t.Errorf("%s: got the Event %q, Black %q and Date %q", test.name,
+				game.Tags.Get("Event"), game.Tags.Get("Black"), game.Tags.Get("Date"))
+		}
+		if test.root.Fen != chess.StartingPosition().String() &&
+			(game.Tags.Get("SetUp") != "1" || game.Tags.Get("FEN") != test.root.Fen) {
+			t.Errorf("%s: got SetUp %q and FEN %q", test.name, game.Tags.Get("SetUp"), game.Tags.Get("FEN"))
+		}
+	}
+}
+
+func TestAppendGameKeepsThePreviousGames(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "new file", content: ""},
+		{name: "no final newline", content: "[Event \"First\"]\n\n1. e4 e5 *"},
+		{name: "final newline", content: "[Event \"First\"]\n\n1. e4 e5 *\n"},
+		{name: "final empty line", content: "[Event \"First\"]\n\n1. e4 e5 *\n\n"},
+	}
+
+	root := &commonTypes.GameMove{Fen: chess.StartingPosition().String()}
+	addLine(t, root, "d4", "d5")
+	pgn := FormatGame(ExportTags(NewTagPairs(), root.Fen, "*"), root)
+
+	for _, test := range tests {
+		path := filepath.Join(t.TempDir(), "games.pgn")
+		if test.content != "" {
+			err := ioutil.WriteFile(path, []byte(test.content), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		err := AppendGame(path, pgn)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		index, err := BuildIndex(context.Background(), path, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		expectedTrees := []string{"d4 d5"}
+		if test.content != "" {
+			expectedTrees = []string{"e4 e5", "d4 d5"}
+		}
+		if len(index.Entries) != len(expectedTrees) {
+			t.Fatalf("%s: got %d games, expected %d", test.name, len(index.Entries), len(expectedTrees))
+		}
+		for gameIndex, expectedTree := range expectedTrees {
+			game, err := index.LoadGame(gameIndex)
+			if err != nil {
+				t.Fatalf("%s: %v", test.name, err)
+			}
+			if tree := describeTree(game.Root); tree != expectedTree {
+				t.Errorf("%s: got the game %q, expected %q", test.name, tree, expectedTree)
+			}
+		}
+	}
+}
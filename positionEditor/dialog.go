@@ -0,0 +1,200 @@
+package positionEditor
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/gookit/ini/v2"
+	"github.com/notnil/chess"
+
+	"github.com/loloof64/chess-pgn-reviser-fyne/chessboard"
+)
+
+// editorLength is the length of the board of the editor, in the dialog.
+const editorLength = 320
+
+// noEnPassant is the en passant choice for positions without en passant cell.
+const noEnPassant = "-"
+
+var enPassantFiles = []string{noEnPassant, "a", "b", "c", "d", "e", "f", "g", "h"}
+
+// errorsKeys gives the locale keys describing the validation errors.
+var errorsKeys = map[error]string{
+	ErrKingsCount:       "positionEditor.kingsCount",
+	ErrTooManyPieces:    "positionEditor.tooManyPieces",
+	ErrPawnsOnBackRanks: "positionEditor.pawnsOnBackRanks",
+	ErrOpponentInCheck:  "positionEditor.opponentInCheck",
+	ErrCastlingRights:   "positionEditor.castlingRights",
+	ErrEnPassant:        "positionEditor.enPassantCell",
+}
+
+// ShowEditor opens the position editor, starting from the given position, and
+// showing the board with the given theme.
+// Once the position is valid, the user can play a game from it, calling
+// onStartGame, or analyze it, calling onAnalyze, with the FEN of the position.
+func ShowEditor(startFen string, theme chessboard.Theme, parent fyne.Window, onStartGame func(fen string), onAnalyze func(fen string)) {
+	editor := NewEditor(editorLength, theme)
+
+	whiteToMove := ini.String("positionEditor.whiteToMove")
+	blackToMove := ini.String("positionEditor.blackToMove")
+	turnChoice := widget.NewRadioGroup([]string{whiteToMove, blackToMove}, nil)
+	turnChoice.Required = true
+	turnChoice.Horizontal = true
+
+	castlingChecks := map[string]*widget.Check{}
+	castlingsBox := container.NewHBox()
+	for _, castling := range castlings {
+		check := widget.NewCheck(castling.letter, nil)
+		castlingChecks[castling.letter] = check
+		castlingsBox.Add(check)
+	}
+
+	enPassantChoice := widget.NewSelect(enPassantFiles, nil)
+
+	fenEntry := widget.NewEntry()
+	validationLabel := widget.NewLabel("")
+	startGameButton := widget.NewButton(ini.String("positionEditor.startGame"), nil)
+	analyzeButton := widget.NewButton(ini.String("positionEditor.analyze"), nil)
+
+	// updating is set while the controls show a new position, so that
+	// their handlers do not edit the position in return.
+	updating := false
+
+	validate := func() {
+		position := editor.EditedPosition()
+		fenEntry.SetText(position.Fen())
+
+		err := position.Validate()
+		if err != nil {
+			validationLabel.SetText(ini.String(errorsKeys[err]))
+			startGameButton.Disable()
+			analyzeButton.Disable()
+			return
+		}
+		validationLabel.SetText("")
+		startGameButton.Enable()
+		analyzeButton.Enable()
+	}
+
+	showPosition := func(position *Position) {
+		updating = true
+		editor.SetPosition(position)
+		if position.Turn == chess.Black {
+			turnChoice.SetSelected(blackToMove)
+		} else {
+			turnChoice.SetSelected(whiteToMove)
+		}
+		for letter, check := range castlingChecks {
+			check.SetChecked(position.Castlings[letter])
+		}
+		if position.EnPassant == chess.NoSquare {
+			enPassantChoice.SetSelected(noEnPassant)
+		} else {
+			enPassantChoice.SetSelected(position.EnPassant.File().String())
+		}
+		updating = false
+		validate()
+	}
+
+	loadFen := func(fen string) {
+		position, err := ParseFen(fen)
+		if err != nil {
+			fmt.Println(err)
+			dialog.ShowInformation(ini.String("positionEditor.title"), ini.String("positionEditor.invalidFen"), parent)
+			return
+		}
+		showPosition(position)
+	}
+
+	// setEnPassant puts the en passant cell on the chosen file, the rank
+	// depending on the side to move.
+	setEnPassant := func() {
+		position := editor.EditedPosition()
+		position.EnPassant = chess.NoSquare
+		for fileIndex, file := range enPassantFiles[1:] {
+			if file != enPassantChoice.Selected {
+				continue
+			}
+			rank := chess.Rank6
+			if position.Turn == chess.Black {
+				rank = chess.Rank3
+			}
+			position.EnPassant = chess.NewSquare(chess.File(fileIndex), rank)
+		}
+	}
+
+	editor.SetOnChangedHandler(validate)
+	turnChoice.OnChanged = func(selected string) {
+		if updating {
+			return
+		}
+		editor.EditedPosition().Turn = chess.White
+		if selected == blackToMove {
+			editor.EditedPosition().Turn = chess.Black
+		}
+		setEnPassant()
+		validate()
+	}
+	for letter, check := range castlingChecks {
+		letter := letter
+		check.OnChanged = func(checked bool) {
+			if updating {
+				return
+			}
+			editor.EditedPosition().Castlings[letter] = checked
+			validate()
+		}
+	}
+	enPassantChoice.OnChanged = func(string) {
+		if updating {
+			return
+		}
+		setEnPassant()
+		validate()
+	}
+	fenEntry.OnSubmitted = loadFen
+
+	copyButton := widget.NewButton(ini.String("positionEditor.copyFen"), func() {
+		parent.Clipboard().SetContent(editor.EditedPosition().Fen())
+	})
+	pasteButton := widget.NewButton(ini.String("positionEditor.pasteFen"), func() {
+		loadFen(parent.Clipboard().Content())
+	})
+	startPositionButton := widget.NewButton(ini.String("positionEditor.startPosition"), func() {
+		loadFen(chess.StartingPosition().String())
+	})
+	clearBoardButton := widget.NewButton(ini.String("positionEditor.clearBoard"), func() {
+		showPosition(NewEmptyPosition())
+	})
+
+	controls := container.NewVBox(
+		turnChoice,
+		widget.NewLabel(ini.String("positionEditor.castling")),
+		castlingsBox,
+		widget.NewLabel(ini.String("positionEditor.enPassant")),
+		enPassantChoice,
+		startPositionButton,
+		clearBoardButton,
+		validationLabel,
+	)
+	fenBox := container.NewBorder(nil, nil, nil, container.NewHBox(copyButton, pasteButton), fenEntry)
+	actions := container.NewHBox(startGameButton, analyzeButton)
+	content := container.NewBorder(nil, container.NewVBox(fenBox, actions), nil, controls, editor)
+
+	editorDialog := dialog.NewCustom(ini.String("positionEditor.title"), ini.String("general.cancelButton"), content, parent)
+	startGameButton.OnTapped = func() {
+		editorDialog.Hide()
+		onStartGame(editor.EditedPosition().Fen())
+	}
+	analyzeButton.OnTapped = func() {
+		editorDialog.Hide()
+		onAnalyze(editor.EditedPosition().Fen())
+	}
+
+	showPosition(NewEmptyPosition())
+	loadFen(startFen)
+	editorDialog.Show()
+}
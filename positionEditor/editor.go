@@ -0,0 +1,241 @@
+package positionEditor
+
+import (
+	"image/color"
+	"math"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/widget"
+	"github.com/notnil/chess"
+
+	"github.com/loloof64/chess-pgn-reviser-fyne/chessboard"
+)
+
+// paletteTop is the top of the palette, in cells, below the board.
+const paletteTop = 8.5
+
+// editorHeight is the height of the editor, in cells: the board, then the two rows of the palette.
+const editorHeight = paletteTop + 2
+
+var (
+	paletteCellColor  = color.RGBA{180, 180, 180, 0xff}
+	selectedCellColor = color.RGBA{20, 255, 30, 0xff}
+)
+
+// Editor edits the pieces of a position, white being at the bottom of the
+// board, above a palette holding the white pieces, then the black ones.
+// Pieces are dragged from the palette or from another cell, and dragged off
+// the board to be removed. Tapping a piece of the palette selects it, then
+// tapping a cell puts it there, or removes it if it is already there.
+type Editor struct {
+	widget.BaseWidget
+
+	length        float32
+	theme         chessboard.Theme
+	position      *Position
+	selectedPiece chess.Piece
+	onChanged     func()
+
+	dragging     bool
+	draggedPiece chess.Piece
+	dragLocation fyne.Position
+}
+
+// NewEditor creates an editor of the given board length, showing an empty
+// position with the colors and the pieces of the given theme.
+func NewEditor(length float32, theme chessboard.Theme) *Editor {
+	editor := &Editor{length: length, theme: theme, position: NewEmptyPosition()}
+	editor.ExtendBaseWidget(editor)
+
+	return editor
+}
+
+// SetOnChangedHandler sets the handler called when the user moves a piece.
+func (editor *Editor) SetOnChangedHandler(handler func()) {
+	editor.onChanged = handler
+}
+
+// SetPosition replaces the edited position.
+func (editor *Editor) SetPosition(position *Position) {
+	editor.position = position
+	editor.Refresh()
+}
+
+// EditedPosition returns the edited position.
+func (editor *Editor) EditedPosition() *Position {
+	return editor.position
+}
+
+// Tapped selects a piece of the palette, or puts the selected piece on a cell.
+func (editor *Editor) Tapped(event *fyne.PointEvent) {
+	if piece, found := editor.palettePieceAt(event.Position); found {
+		if piece == editor.selectedPiece {
+			editor.selectedPiece = chess.NoPiece
+		} else {
+			editor.selectedPiece = piece
+		}
+		editor.Refresh()
+		return
+	}
+
+	square, found := editor.squareAt(event.Position)
+	if !found {
+		return
+	}
+	if editor.selectedPiece == chess.NoPiece || editor.position.Pieces[square] == editor.selectedPiece {
+		delete(editor.position.Pieces, square)
+	} else {
+		editor.position.Pieces[square] = editor.selectedPiece
+	}
+	editor.notifyChange()
+}
+
+// Dragged moves the piece taken from the palette or from a cell.
+func (editor *Editor) Dragged(event *fyne.DragEvent) {
+	if !editor.dragging {
+		startPosition := event.Position.Subtract(event.Dragged)
+		if piece, found := editor.palettePieceAt(startPosition); found {
+			editor.draggedPiece = piece
+		} else if square, found := editor.squareAt(startPosition); found && editor.position.Pieces[square] != chess.NoPiece {
+			editor.draggedPiece = editor.position.Pieces[square]
+			delete(editor.position.Pieces, square)
+		} else {
+			return
+		}
+		editor.dragging = true
+	}
+
+	cellsLength := editor.cellsLength(editor.Size())
+	editor.dragLocation = event.Position.Subtract(fyne.NewPos(cellsLength/2, cellsLength/2))
+	editor.Refresh()
+}
+
+// DragEnd puts the dragged piece on the cell under the pointer, or removes it outside of the board.
+func (editor *Editor) DragEnd() {
+	if !editor.dragging {
+		return
+	}
+	editor.dragging = false
+
+	cellsLength := editor.cellsLength(editor.Size())
+	dropPosition := editor.dragLocation.Add(fyne.NewPos(cellsLength/2, cellsLength/2))
+	if square, found := editor.squareAt(dropPosition); found {
+		editor.position.Pieces[square] = editor.draggedPiece
+	}
+	editor.notifyChange()
+}
+
+func (editor *Editor) notifyChange() {
+	editor.Refresh()
+	if editor.onChanged != nil {
+		editor.onChanged()
+	}
+}
+
+func (editor *Editor) cellsLength(size fyne.Size) float32 {
+	return float32(math.Min(float64(size.Width)/8, float64(size.Height)/editorHeight))
+}
+
+func (editor *Editor) squareAt(position fyne.Position) (chess.Square, bool) {
+	cellsLength := editor.cellsLength(editor.Size())
+	file := int(math.Floor(float64(position.X / cellsLength)))
+	row := int(math.Floor(float64(position.Y / cellsLength)))
+	if file < 0 || file > 7 || row < 0 || row > 7 {
+		return chess.NoSquare, false
+	}
+	return chess.NewSquare(chess.File(file), chess.Rank(7-row)), true
+}
+
+func (editor *Editor) palettePieceAt(position fyne.Position) (chess.Piece, bool) {
+	cellsLength := editor.cellsLength(editor.Size())
+	column := int(math.Floor(float64(position.X / cellsLength)))
+	row := int(math.Floor(float64(position.Y/cellsLength - paletteTop)))
+	if position.Y < paletteTop*cellsLength || column < 0 || column > 5 || row > 1 {
+		return chess.NoPiece, false
+	}
+	return allPieces[6*row+column], true
+}
+
+// CreateRenderer creates the renderer of the editor.
+func (editor *Editor) CreateRenderer() fyne.WidgetRenderer {
+	editor.ExtendBaseWidget(editor)
+
+	return &editorRenderer{editor: editor}
+}
+
+type editorRenderer struct {
+	editor  *Editor
+	objects []fyne.CanvasObject
+}
+
+// Layout builds the cells and the pieces again, for the given size.
+func (renderer *editorRenderer) Layout(size fyne.Size) {
+	editor := renderer.editor
+	cellsLength := editor.cellsLength(size)
+	cellsSize := fyne.NewSize(cellsLength, cellsLength)
+	renderer.objects = nil
+
+	addCell := func(cellColor color.Color, position fyne.Position, piece chess.Piece) {
+		cell := canvas.NewRectangle(cellColor)
+		cell.Resize(cellsSize)
+		cell.Move(position)
+		renderer.objects = append(renderer.objects, cell)
+		if piece != chess.NoPiece {
+			pieceImage := canvas.NewImageFromResource(editor.theme.Pieces.Picture(piece))
+			pieceImage.Resize(cellsSize)
+			pieceImage.Move(position)
+			renderer.objects = append(renderer.objects, pieceImage)
+		}
+	}
+
+	for rank := 0; rank < 8; rank++ {
+		for file := 0; file < 8; file++ {
+			cellColor := editor.theme.Colors.BlackCells
+			if (file+rank)%2 > 0 {
+				cellColor = editor.theme.Colors.WhiteCells
+			}
+			square := chess.NewSquare(chess.File(file), chess.Rank(rank))
+			position := fyne.NewPos(float32(file)*cellsLength, float32(7-rank)*cellsLength)
+			addCell(cellColor, position, editor.position.Pieces[square])
+		}
+	}
+
+	for pieceIndex, piece := range allPieces {
+		cellColor := color.Color(paletteCellColor)
+		if piece == editor.selectedPiece {
+			cellColor = selectedCellColor
+		}
+		position := fyne.NewPos(float32(pieceIndex%6)*cellsLength, (paletteTop+float32(pieceIndex/6))*cellsLength)
+		addCell(cellColor, position, piece)
+	}
+
+	if editor.dragging {
+		draggedImage := canvas.NewImageFromResource(editor.theme.Pieces.Picture(editor.draggedPiece))
+		draggedImage.Resize(cellsSize)
+		draggedImage.Move(editor.dragLocation)
+		renderer.objects = append(renderer.objects, draggedImage)
+	}
+}
+
+// MinSize computes the minimum size.
+func (renderer *editorRenderer) MinSize() fyne.Size {
+	cellsLength := renderer.editor.length / 8
+	return fyne.NewSize(renderer.editor.length, cellsLength*editorHeight)
+}
+
+// Refresh refreshes the editor.
+func (renderer *editorRenderer) Refresh() {
+	renderer.Layout(renderer.editor.Size())
+	canvas.Refresh(renderer.editor)
+}
+
+// Objects returns the objects of the canvas of the renderer.
+func (renderer *editorRenderer) Objects() []fyne.CanvasObject {
+	return renderer.objects
+}
+
+// Destroy cleans up the renderer.
+func (renderer *editorRenderer) Destroy() {
+
+}
@@ -0,0 +1,226 @@
+package positionEditor
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/notnil/chess"
+)
+
+var (
+	// ErrKingsCount says that a side has no king, or more than one.
+	ErrKingsCount = errors.New("each side must have exactly one king")
+
+	// ErrTooManyPieces says that a side has more than 16 pieces, or more than 8 pawns.
+	ErrTooManyPieces = errors.New("a side has too many pieces")
+
+	// ErrPawnsOnBackRanks says that a pawn stands on the first or the last rank.
+	ErrPawnsOnBackRanks = errors.New("pawns cannot stand on the first or the last rank")
+
+	// ErrOpponentInCheck says that the king of the side which has just moved is in check.
+	ErrOpponentInCheck = errors.New("the side not to move is in check")
+
+	// ErrCastlingRights says that a castling right is set without the king and the rook on their start cells.
+	ErrCastlingRights = errors.New("castling rights do not match the kings and rooks")
+
+	// ErrEnPassant says that no pawn can have just made the double step the en passant cell implies.
+	ErrEnPassant = errors.New("invalid en passant cell")
+)
+
+// castlings lists the castling rights, with their FEN letter and the start
+// cells of the king and of the rook they need.
+var castlings = []struct {
+	letter     string
+	color      chess.Color
+	side       chess.Side
+	kingSquare chess.Square
+	rookSquare chess.Square
+}{
+	{"K", chess.White, chess.KingSide, chess.E1, chess.H1},
+	{"Q", chess.White, chess.QueenSide, chess.E1, chess.A1},
+	{"k", chess.Black, chess.KingSide, chess.E8, chess.H8},
+	{"q", chess.Black, chess.QueenSide, chess.E8, chess.A8},
+}
+
+// allPieces are the pieces, the white ones first, in the palette order.
+var allPieces = []chess.Piece{
+	chess.WhiteKing, chess.WhiteQueen, chess.WhiteRook, chess.WhiteBishop, chess.WhiteKnight, chess.WhitePawn,
+	chess.BlackKing, chess.BlackQueen, chess.BlackRook, chess.BlackBishop, chess.BlackKnight, chess.BlackPawn,
+}
+
+func pieceOf(pieceType chess.PieceType, color chess.Color) chess.Piece {
+	for _, piece := range allPieces {
+		if piece.Type() == pieceType && piece.Color() == color {
+			return piece
+		}
+	}
+	return chess.NoPiece
+}
+
+// Position is an edited position, which may be illegal until validated.
+type Position struct {
+	Pieces map[chess.Square]chess.Piece
+	Turn   chess.Color
+
+	// Castlings holds the castling rights, by their FEN letter: K, Q, k or q.
+	Castlings map[string]bool
+
+	// EnPassant is the cell behind the pawn which has just made a double step,
+	// or chess.NoSquare.
+	EnPassant chess.Square
+}
+
+// NewEmptyPosition returns a position without any piece, white to move.
+func NewEmptyPosition() *Position {
+	return &Position{
+		Pieces:    map[chess.Square]chess.Piece{},
+		Turn:      chess.White,
+		Castlings: map[string]bool{},
+		EnPassant: chess.NoSquare,
+	}
+}
+
+// ParseFen reads a position in Forsyth-Edwards Notation. The position is not validated.
+func ParseFen(fen string) (*Position, error) {
+	decoded := &chess.Position{}
+	err := decoded.UnmarshalText([]byte(strings.TrimSpace(fen)))
+	if err != nil {
+		return nil, err
+	}
+
+	position := NewEmptyPosition()
+	for square, piece := range decoded.Board().SquareMap() {
+		position.Pieces[square] = piece
+	}
+	position.Turn = decoded.Turn()
+	for _, castling := range castlings {
+		position.Castlings[castling.letter] = decoded.CastleRights().CanCastle(castling.color, castling.side)
+	}
+	position.EnPassant = enPassantSquareOf(fen)
+	return position, nil
+}
+
+// enPassantSquareOf reads the en passant field of a FEN, which the chess
+// positions do not expose.
+func enPassantSquareOf(fen string) chess.Square {
+	fields := strings.Fields(fen)
+	if len(fields) < 4 || len(fields[3]) != 2 {
+		return chess.NoSquare
+	}
+	file := int(fields[3][0]) - 'a'
+	rank := int(fields[3][1]) - '1'
+	if file < 0 || file > 7 || rank < 0 || rank > 7 {
+		return chess.NoSquare
+	}
+	return chess.NewSquare(chess.File(file), chess.Rank(rank))
+}
+
+// Fen returns the position in Forsyth-Edwards Notation, as the first move of a game.
+func (position *Position) Fen() string {
+	castlingRights := ""
+	for _, castling := range castlings {
+		if position.Castlings[castling.letter] {
+			castlingRights += castling.letter
+		}
+	}
+	if castlingRights == "" {
+		castlingRights = "-"
+	}
+
+	enPassant := "-"
+	if position.EnPassant != chess.NoSquare {
+		enPassant = position.EnPassant.String()
+	}
+
+	turn := "w"
+	if position.Turn == chess.Black {
+		turn = "b"
+	}
+
+	return fmt.Sprintf("%s %s %s %s 0 1", chess.NewBoard(position.Pieces).String(), turn, castlingRights, enPassant)
+}
+
+// Validate returns one of the Err errors of this package if the position is illegal, nil otherwise.
+func (position *Position) Validate() error {
+	kingsCount := map[chess.Color]int{}
+	piecesCount := map[chess.Color]int{}
+	pawnsCount := map[chess.Color]int{}
+	for square, piece := range position.Pieces {
+		piecesCount[piece.Color()]++
+		switch piece.Type() {
+		case chess.King:
+			kingsCount[piece.Color()]++
+		case chess.Pawn:
+			pawnsCount[piece.Color()]++
+			if square.Rank() == chess.Rank1 || square.Rank() == chess.Rank8 {
+				return ErrPawnsOnBackRanks
+			}
+		}
+	}
+
+	for _, color := range []chess.Color{chess.White, chess.Black} {
+		if kingsCount[color] != 1 {
+			return ErrKingsCount
+		}
+		if piecesCount[color] > 16 || pawnsCount[color] > 8 {
+			return ErrTooManyPieces
+		}
+	}
+
+	for _, castling := range castlings {
+		if !position.Castlings[castling.letter] {
+			continue
+		}
+		kingInPlace := position.Pieces[castling.kingSquare] == pieceOf(chess.King, castling.color)
+		rookInPlace := position.Pieces[castling.rookSquare] == pieceOf(chess.Rook, castling.color)
+		if !kingInPlace || !rookInPlace {
+			return ErrCastlingRights
+		}
+	}
+
+	if position.EnPassant != chess.NoSquare && !position.enPassantIsPossible() {
+		return ErrEnPassant
+	}
+
+	if position.opponentIsInCheck() {
+		return ErrOpponentInCheck
+	}
+
+	return nil
+}
+
+// enPassantIsPossible says whether a pawn of the side not to move can have
+// just made a double step over the en passant cell.
+func (position *Position) enPassantIsPossible() bool {
+	square := position.EnPassant
+	passedRank, pawnRank, startRank := chess.Rank6, chess.Rank5, chess.Rank7
+	if position.Turn == chess.Black {
+		passedRank, pawnRank, startRank = chess.Rank3, chess.Rank4, chess.Rank2
+	}
+	if square.Rank() != passedRank {
+		return false
+	}
+
+	movedPawn := pieceOf(chess.Pawn, position.Turn.Other())
+	return position.Pieces[chess.NewSquare(square.File(), pawnRank)] == movedPawn &&
+		position.Pieces[square] == chess.NoPiece &&
+		position.Pieces[chess.NewSquare(square.File(), startRank)] == chess.NoPiece
+}
+
+// opponentIsInCheck says whether the side to move could take the king of the other side.
+func (position *Position) opponentIsInCheck() bool {
+	decoded := &chess.Position{}
+	err := decoded.UnmarshalText([]byte(position.Fen()))
+	if err != nil {
+		return false
+	}
+
+	opponentKing := pieceOf(chess.King, position.Turn.Other())
+	for _, move := range decoded.ValidMoves() {
+		if decoded.Board().Piece(move.S2()) == opponentKing {
+			return true
+		}
+	}
+	return false
+}
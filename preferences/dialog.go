@@ -0,0 +1,186 @@
+package preferences
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/gookit/ini/v2"
+	"github.com/notnil/chess"
+
+	"github.com/loloof64/chess-pgn-reviser-fyne/chessboard"
+	"github.com/loloof64/chess-pgn-reviser-fyne/commonTypes"
+)
+
+// maxOpponentMoveDelay is the longest opponent move delay proposed by the settings dialog.
+const maxOpponentMoveDelay = 3 * time.Second
+
+// previewLength is the length of the board previewing the theme in the settings dialog.
+const previewLength = 200
+
+// ShowSettingsDialog lets the user edit the preferences, which are changed
+// when the dialog is confirmed.
+func ShowSettingsDialog(preferences *Preferences, parent fyne.Window) {
+	whiteAtBottom := ini.String("settings.whiteAtBottom")
+	blackAtBottom := ini.String("settings.blackAtBottom")
+	orientationChoice := widget.NewRadioGroup([]string{whiteAtBottom, blackAtBottom}, nil)
+	orientationChoice.Required = true
+	if preferences.BoardOrientation() == chessboard.BlackAtBottom {
+		orientationChoice.SetSelected(blackAtBottom)
+	} else {
+		orientationChoice.SetSelected(whiteAtBottom)
+	}
+
+	evaluationBarCheck := widget.NewCheck("", nil)
+	evaluationBarCheck.SetChecked(preferences.EvaluationBarShown())
+
+	blunderReportCheck := widget.NewCheck("", nil)
+	blunderReportCheck.SetChecked(preferences.BlunderReportEnabled())
+
+	delayLabel := widget.NewLabel("")
+	delaySlider := widget.NewSlider(0, float64(maxOpponentMoveDelay/time.Millisecond))
+	delaySlider.Step = 100
+	delaySlider.OnChanged = func(milliseconds float64) {
+		delayLabel.SetText(fmt.Sprintf("%.0f ms", milliseconds))
+	}
+	delaySlider.SetValue(float64(preferences.OpponentMoveDelay() / time.Millisecond))
+
+	displayModeChoices := []string{}
+	for _, mode := range chessboard.DisplayModes {
+		displayModeChoices = append(displayModeChoices, displayModeName(mode))
+	}
+	displayModeChoice := widget.NewSelect(displayModeChoices, nil)
+	displayModeChoice.SetSelected(displayModeName(preferences.DisplayMode()))
+
+	colorSchemeNames := map[string]string{}
+	colorSchemeChoices := []string{}
+	for _, scheme := range chessboard.ColorSchemes {
+		displayedName := ini.String("boardThemes." + scheme.Name)
+		colorSchemeNames[displayedName] = scheme.Name
+		colorSchemeChoices = append(colorSchemeChoices, displayedName)
+	}
+	pieceSetNames := map[string]string{}
+	pieceSetChoices := []string{}
+	for _, set := range chessboard.PieceSets {
+		displayedName := ini.String("boardThemes." + set.Name)
+		pieceSetNames[displayedName] = set.Name
+		pieceSetChoices = append(pieceSetChoices, displayedName)
+	}
+	customPieceSet := ini.String("settings.customPieceSet")
+	pieceSetNames[customPieceSet] = CustomPieceSet
+	pieceSetChoices = append(pieceSetChoices, customPieceSet)
+
+	// The preview shows the chosen theme right away, before it is confirmed.
+	preview := chessboard.NewChessBoard(previewLength, &parent)
+	preview.RequestHistoryPosition(commonTypes.GameMove{Fen: chess.StartingPosition().String()})
+	colorSchemeChoice := widget.NewSelect(colorSchemeChoices, nil)
+	pieceSetChoice := widget.NewSelect(pieceSetChoices, nil)
+	piecesDirectory := preferences.PiecesDirectory()
+	piecesDirectoryLabel := widget.NewLabel(piecesDirectory)
+
+	updatePreview := func() {
+		theme, err := boardTheme(colorSchemeNames[colorSchemeChoice.Selected],
+			pieceSetNames[pieceSetChoice.Selected], piecesDirectory)
+		if err != nil {
+			fmt.Println(err)
+		}
+		preview.SetTheme(theme)
+	}
+	colorSchemeChoice.OnChanged = func(string) {
+		updatePreview()
+	}
+	pieceSetChoice.OnChanged = func(string) {
+		updatePreview()
+	}
+
+	piecesDirectoryButton := widget.NewButton(ini.String("settings.choosePiecesDirectory"), func() {
+		dialog.ShowFolderOpen(func(directory fyne.ListableURI, err error) {
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			if directory == nil {
+				return
+			}
+			_, err = chessboard.LoadPieceSet(directory.Path())
+			if err != nil {
+				fmt.Println(err)
+				dialog.ShowInformation(ini.String("settings.dialogTitle"), ini.String("settings.invalidPiecesDirectory"), parent)
+				return
+			}
+			piecesDirectory = directory.Path()
+			piecesDirectoryLabel.SetText(piecesDirectory)
+			pieceSetChoice.SetSelected(customPieceSet)
+			updatePreview()
+		}, parent)
+	})
+
+	for displayedName, name := range colorSchemeNames {
+		if name == chessboard.ColorSchemeByName(preferences.ColorScheme()).Name {
+			colorSchemeChoice.SetSelected(displayedName)
+		}
+	}
+	for displayedName, name := range pieceSetNames {
+		if name == preferences.PieceSet() {
+			pieceSetChoice.SetSelected(displayedName)
+		}
+	}
+	if pieceSetChoice.Selected == "" {
+		pieceSetChoice.SetSelected(pieceSetChoices[0])
+	}
+
+	form := widget.NewForm(
+		widget.NewFormItem(ini.String("settings.boardOrientation"), orientationChoice),
+		widget.NewFormItem(ini.String("settings.evaluationBar"), evaluationBarCheck),
+		widget.NewFormItem(ini.String("settings.blunderReport"), blunderReportCheck),
+		widget.NewFormItem(ini.String("settings.opponentMoveDelay"),
+			container.NewBorder(nil, nil, nil, delayLabel, delaySlider)),
+		widget.NewFormItem(ini.String("settings.displayMode"), displayModeChoice),
+		widget.NewFormItem(ini.String("settings.colorScheme"), colorSchemeChoice),
+		widget.NewFormItem(ini.String("settings.pieceSet"), pieceSetChoice),
+		widget.NewFormItem(ini.String("settings.piecesDirectory"),
+			container.NewBorder(nil, nil, nil, piecesDirectoryButton, piecesDirectoryLabel)),
+	)
+	content := container.NewBorder(nil, nil, nil, preview, form)
+
+	settingsDialog := dialog.NewCustomConfirm(ini.String("settings.dialogTitle"),
+		ini.String("general.okButton"), ini.String("general.cancelButton"), content, func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+
+			if orientationChoice.Selected == blackAtBottom {
+				preferences.SetBoardOrientation(chessboard.BlackAtBottom)
+			} else {
+				preferences.SetBoardOrientation(chessboard.BlackAtTop)
+			}
+			preferences.SetEvaluationBarShown(evaluationBarCheck.Checked)
+			preferences.SetBlunderReportEnabled(blunderReportCheck.Checked)
+			preferences.SetOpponentMoveDelay(time.Duration(delaySlider.Value) * time.Millisecond)
+			for _, mode := range chessboard.DisplayModes {
+				if displayModeName(mode) == displayModeChoice.Selected {
+					preferences.SetDisplayMode(mode)
+				}
+			}
+			preferences.SetPiecesDirectory(piecesDirectory)
+			preferences.SetColorScheme(colorSchemeNames[colorSchemeChoice.Selected])
+			preferences.SetPieceSet(pieceSetNames[pieceSetChoice.Selected])
+		}, parent)
+	settingsDialog.Show()
+}
+
+// displayModeNames give the locale keys of the names of the display modes.
+var displayModeNames = map[chessboard.DisplayMode]string{
+	chessboard.ShowAllPieces:       "displayModes.showAll",
+	chessboard.HideAllPieces:       "displayModes.hideAll",
+	chessboard.HideOpponentPieces:  "displayModes.hideOpponent",
+	chessboard.ShowPiecesAfterMove: "displayModes.afterMove",
+}
+
+func displayModeName(mode chessboard.DisplayMode) string {
+	return ini.String(displayModeNames[mode])
+}
@@ -0,0 +1,281 @@
+// Package preferences keeps the options of the user between launches, with
+// the preferences of the Fyne application.
+package preferences
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"github.com/notnil/chess"
+
+	"github.com/loloof64/chess-pgn-reviser-fyne/chessboard"
+)
+
+// AppID identifies the application, so that Fyne knows where to store its preferences.
+const AppID = "com.loloof64.chess-pgn-reviser-fyne"
+
+const (
+	boardOrientationKey  = "boardOrientation"
+	windowWidthKey       = "windowWidth"
+	windowHeightKey      = "windowHeight"
+	languageKey          = "language"
+	lastDirectoryKey     = "lastDirectory"
+	trainedSideKey       = "trainedSide"
+	enginePathKey        = "enginePath"
+	evaluationBarKey     = "evaluationBar"
+	opponentMoveDelayKey = "opponentMoveDelay"
+	blunderReportKey     = "blunderReport"
+	colorSchemeKey       = "colorScheme"
+	pieceSetKey          = "pieceSet"
+	piecesDirectoryKey   = "piecesDirectory"
+	displayModeKey       = "displayMode"
+	clockTotalKey        = "clockTotal"
+	clockIncrementKey    = "clockIncrement"
+	clockPerMoveKey      = "clockPerMove"
+)
+
+// CustomPieceSet is the piece set name standing for the pieces read from the user pieces directory.
+const CustomPieceSet = "custom"
+
+// defaultOpponentMoveDelay is the opponent move delay until the user chooses another one.
+const defaultOpponentMoveDelay = 400 * time.Millisecond
+
+// Preferences gives typed access to the stored options, and notifies their changes.
+type Preferences struct {
+	store          fyne.Preferences
+	listeners      map[int]func()
+	nextListenerID int
+}
+
+// New wraps the given Fyne preferences, usually those of the current application.
+func New(store fyne.Preferences) *Preferences {
+	return &Preferences{store: store, listeners: map[int]func(){}}
+}
+
+// AddChangeListener registers a listener called after each change of an
+// option, so that it can take effect right away. The returned function
+// removes the listener.
+func (preferences *Preferences) AddChangeListener(listener func()) func() {
+	listenerID := preferences.nextListenerID
+	preferences.nextListenerID++
+	preferences.listeners[listenerID] = listener
+
+	return func() {
+		delete(preferences.listeners, listenerID)
+	}
+}
+
+func (preferences *Preferences) notifyChange() {
+	for _, listener := range preferences.listeners {
+		listener()
+	}
+}
+
+// BoardOrientation returns the side where the black pieces are shown.
+func (preferences *Preferences) BoardOrientation() chessboard.BlackSide {
+	return chessboard.BlackSide(preferences.store.IntWithFallback(boardOrientationKey, int(chessboard.BlackAtTop)))
+}
+
+// SetBoardOrientation sets the side where the black pieces are shown.
+func (preferences *Preferences) SetBoardOrientation(orientation chessboard.BlackSide) {
+	preferences.store.SetInt(boardOrientationKey, int(orientation))
+	preferences.notifyChange()
+}
+
+// WindowSize returns the size of the main window when it was last closed,
+// and false if it has never been stored.
+func (preferences *Preferences) WindowSize() (fyne.Size, bool) {
+	width := preferences.store.Float(windowWidthKey)
+	height := preferences.store.Float(windowHeightKey)
+	if width <= 0 || height <= 0 {
+		return fyne.Size{}, false
+	}
+	return fyne.NewSize(float32(width), float32(height)), true
+}
+
+// SetWindowSize stores the size of the main window.
+func (preferences *Preferences) SetWindowSize(size fyne.Size) {
+	preferences.store.SetFloat(windowWidthKey, float64(size.Width))
+	preferences.store.SetFloat(windowHeightKey, float64(size.Height))
+}
+
+// Language returns the code of the language of the interface, such as fr,
+// or an empty string to follow the system language.
+func (preferences *Preferences) Language() string {
+	return preferences.store.String(languageKey)
+}
+
+// SetLanguage sets the code of the language of the interface, an empty string following the system language.
+func (preferences *Preferences) SetLanguage(language string) {
+	preferences.store.SetString(languageKey, language)
+	preferences.notifyChange()
+}
+
+// LastDirectory returns the directory of the last opened PGN file, or an empty string.
+func (preferences *Preferences) LastDirectory() string {
+	return preferences.store.String(lastDirectoryKey)
+}
+
+// SetLastDirectory sets the directory of the last opened PGN file.
+func (preferences *Preferences) SetLastDirectory(directory string) {
+	preferences.store.SetString(lastDirectoryKey, directory)
+}
+
+// TrainedSide returns the side the user explicitly chose for a revision, and
+// whether there is one.
+func (preferences *Preferences) TrainedSide() (chess.Color, bool) {
+	switch preferences.store.String(trainedSideKey) {
+	case "white":
+		return chess.White, true
+	case "black":
+		return chess.Black, true
+	}
+	return chess.NoColor, false
+}
+
+// SetTrainedSide sets the side the user explicitly chose for a revision.
+func (preferences *Preferences) SetTrainedSide(side chess.Color) {
+	value := "white"
+	if side == chess.Black {
+		value = "black"
+	}
+	preferences.store.SetString(trainedSideKey, value)
+}
+
+// TimeControl returns the time control the user chose for the last revision.
+func (preferences *Preferences) TimeControl() chessboard.TimeControl {
+	return chessboard.TimeControl{
+		Total:     time.Duration(preferences.store.Int(clockTotalKey)) * time.Second,
+		Increment: time.Duration(preferences.store.Int(clockIncrementKey)) * time.Second,
+		PerMove:   time.Duration(preferences.store.Int(clockPerMoveKey)) * time.Second,
+	}
+}
+
+// SetTimeControl sets the time control the user chose for the last revision.
+func (preferences *Preferences) SetTimeControl(control chessboard.TimeControl) {
+	preferences.store.SetInt(clockTotalKey, int(control.Total/time.Second))
+	preferences.store.SetInt(clockIncrementKey, int(control.Increment/time.Second))
+	preferences.store.SetInt(clockPerMoveKey, int(control.PerMove/time.Second))
+}
+
+// EnginePath returns the path of the analysis engine, or an empty string.
+func (preferences *Preferences) EnginePath() string {
+	return preferences.store.String(enginePathKey)
+}
+
+// SetEnginePath sets the path of the analysis engine.
+func (preferences *Preferences) SetEnginePath(path string) {
+	preferences.store.SetString(enginePathKey, path)
+	preferences.notifyChange()
+}
+
+// EvaluationBarShown says whether the evaluation bar is shown beside the board.
+func (preferences *Preferences) EvaluationBarShown() bool {
+	return preferences.store.Bool(evaluationBarKey)
+}
+
+// SetEvaluationBarShown shows or hides the evaluation bar beside the board.
+func (preferences *Preferences) SetEvaluationBarShown(shown bool) {
+	preferences.store.SetBool(evaluationBarKey, shown)
+	preferences.notifyChange()
+}
+
+// OpponentMoveDelay returns the pause before the computer plays the opponent's moves.
+func (preferences *Preferences) OpponentMoveDelay() time.Duration {
+	milliseconds := preferences.store.IntWithFallback(opponentMoveDelayKey,
+		int(defaultOpponentMoveDelay/time.Millisecond))
+	return time.Duration(milliseconds) * time.Millisecond
+}
+
+// SetOpponentMoveDelay sets the pause before the computer plays the opponent's moves.
+func (preferences *Preferences) SetOpponentMoveDelay(delay time.Duration) {
+	preferences.store.SetInt(opponentMoveDelayKey, int(delay/time.Millisecond))
+	preferences.notifyChange()
+}
+
+// BlunderReportEnabled says whether the moves of the user are analyzed after each revision.
+func (preferences *Preferences) BlunderReportEnabled() bool {
+	return preferences.store.BoolWithFallback(blunderReportKey, true)
+}
+
+// SetBlunderReportEnabled sets whether the moves of the user are analyzed after each revision.
+func (preferences *Preferences) SetBlunderReportEnabled(enabled bool) {
+	preferences.store.SetBool(blunderReportKey, enabled)
+	preferences.notifyChange()
+}
+
+// ColorScheme returns the name of the color scheme of the board.
+func (preferences *Preferences) ColorScheme() string {
+	return preferences.store.StringWithFallback(colorSchemeKey, chessboard.ColorSchemes[0].Name)
+}
+
+// SetColorScheme sets the name of the color scheme of the board.
+func (preferences *Preferences) SetColorScheme(name string) {
+	preferences.store.SetString(colorSchemeKey, name)
+	preferences.notifyChange()
+}
+
+// PieceSet returns the name of the piece set of the board, CustomPieceSet
+// standing for the pieces of the user pieces directory.
+func (preferences *Preferences) PieceSet() string {
+	return preferences.store.StringWithFallback(pieceSetKey, chessboard.PieceSets[0].Name)
+}
+
+// SetPieceSet sets the name of the piece set of the board.
+func (preferences *Preferences) SetPieceSet(name string) {
+	preferences.store.SetString(pieceSetKey, name)
+	preferences.notifyChange()
+}
+
+// PiecesDirectory returns the directory of the user piece set, or an empty string.
+func (preferences *Preferences) PiecesDirectory() string {
+	return preferences.store.String(piecesDirectoryKey)
+}
+
+// SetPiecesDirectory sets the directory of the user piece set.
+func (preferences *Preferences) SetPiecesDirectory(directory string) {
+	preferences.store.SetString(piecesDirectoryKey, directory)
+	preferences.notifyChange()
+}
+
+// DisplayMode returns which pieces the board shows, for blindfold training.
+func (preferences *Preferences) DisplayMode() chessboard.DisplayMode {
+	return chessboard.DisplayMode(preferences.store.IntWithFallback(displayModeKey, int(chessboard.ShowAllPieces)))
+}
+
+// SetDisplayMode sets which pieces the board shows.
+func (preferences *Preferences) SetDisplayMode(mode chessboard.DisplayMode) {
+	preferences.store.SetInt(displayModeKey, int(mode))
+	preferences.notifyChange()
+}
+
+// BoardTheme returns the theme of the board matching the preferences. If the
+// user piece set cannot be read, the default pieces are used instead.
+func (preferences *Preferences) BoardTheme() chessboard.Theme {
+	theme, err := boardTheme(preferences.ColorScheme(), preferences.PieceSet(), preferences.PiecesDirectory())
+	if err != nil {
+		fmt.Println(err)
+	}
+	return theme
+}
+
+// boardTheme builds the theme of the given color scheme and piece set. If
+// the user piece set cannot be read, it returns the error along with a theme
+// using the default pieces.
+func boardTheme(colorScheme string, pieceSet string, piecesDirectory string) (chessboard.Theme, error) {
+	theme := chessboard.Theme{
+		Colors: chessboard.ColorSchemeByName(colorScheme),
+		Pieces: chessboard.PieceSetByName(pieceSet),
+	}
+	if pieceSet != CustomPieceSet {
+		return theme, nil
+	}
+
+	customPieces, err := chessboard.LoadPieceSet(piecesDirectory)
+	if err != nil {
+		return theme, err
+	}
+	theme.Pieces = customPieces
+	return theme, nil
+}
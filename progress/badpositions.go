@@ -0,0 +1,47 @@
+package progress
+
+import (
+	"sort"
+	"time"
+)
+
+// BadPosition is a position where a bad move was played, to be drilled again.
+type BadPosition struct {
+	// GameKey is the key of the game the position comes from.
+	GameKey string `json:"gameKey"`
+
+	// Fen is the position, in Forsyth-Edwards Notation.
+	Fen string `json:"fen"`
+
+	// PlayedMove and BestMove are in UCI notation.
+	PlayedMove string `json:"playedMove"`
+	BestMove   string `json:"bestMove"`
+
+	// Classification is the name of the classification of the played move.
+	Classification string `json:"classification"`
+
+	Recorded time.Time `json:"recorded"`
+}
+
+// RecordBadPosition records a bad position, replacing the previous record of
+// the same move in the same position.
+func (store *Store) RecordBadPosition(position BadPosition) {
+	store.BadPositions[position.Fen+" "+position.PlayedMove] = &position
+}
+
+// RemoveBadPosition forgets a bad position, once the user has drilled it successfully.
+func (store *Store) RemoveBadPosition(position BadPosition) {
+	delete(store.BadPositions, position.Fen+" "+position.PlayedMove)
+}
+
+// BadPositionsList returns the recorded bad positions, from the oldest to the newest.
+func (store *Store) BadPositionsList() []BadPosition {
+	positions := []BadPosition{}
+	for _, position := range store.BadPositions {
+		positions = append(positions, *position)
+	}
+	sort.Slice(positions, func(first int, second int) bool {
+		return positions[first].Recorded.Before(positions[second].Recorded)
+	})
+	return positions
+}
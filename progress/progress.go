@@ -0,0 +1,171 @@
+package progress
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// storeDirectory is the directory of the store, inside the user configuration directory.
+const storeDirectory = "chess-pgn-reviser-fyne"
+
+// storeFileName is the file name of the store.
+const storeFileName = "progress.json"
+
+// LineOutcome records how the user did along a line of a game.
+type LineOutcome struct {
+	// Completed says whether the end of the line has been reached at least once.
+	Completed bool `json:"completed"`
+
+	// Attempts is the count of revisions having followed the line.
+	Attempts int `json:"attempts"`
+
+	// Mistakes is the count of wrong moves played along the line, over all attempts.
+	Mistakes int `json:"mistakes"`
+
+	LastPlayed time.Time `json:"lastPlayed"`
+}
+
+// GameProgress records the lines revised in a game.
+type GameProgress struct {
+	// TotalLines is the count of lines of the game, when it was last revised.
+	TotalLines int `json:"totalLines"`
+
+	// Lines are the outcomes of the revised lines, by moves in UCI notation
+	// separated by spaces.
+	Lines map[string]*LineOutcome `json:"lines"`
+
+	// Schedule plans the next review of the game.
+	Schedule Schedule `json:"schedule"`
+}
+
+// Store is the revision progress of the user, saved on the local disk.
+type Store struct {
+	path string
+
+	// Games are the progress of the revised games, by game key.
+	Games map[string]*GameProgress `json:"games"`
+
+	// BadPositions are the positions where bad moves were played, by position
+	// and played move.
+	BadPositions map[string]*BadPosition `json:"badPositions"`
+}
+
+// DefaultPath returns the path of the store in the user configuration directory.
+func DefaultPath() (string, error) {
+	configDirectory, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDirectory, storeDirectory, storeFileName), nil
+}
+
+// NewStore creates an empty store, saved at the given path. An empty path
+// gives a store which is never saved.
+func NewStore(path string) *Store {
+	return &Store{path: path, Games: map[string]*GameProgress{}, BadPositions: map[string]*BadPosition{}}
+}
+
+// Open loads the store at the given path. A missing file gives an empty store.
+func Open(path string) (*Store, error) {
+	store := NewStore(path)
+
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(content, store)
+	if err != nil {
+		return nil, err
+	}
+	if store.Games == nil {
+		store.Games = map[string]*GameProgress{}
+	}
+	if store.BadPositions == nil {
+		store.BadPositions = map[string]*BadPosition{}
+	}
+
+	return store, nil
+}
+
+// Save writes the store to its file, replacing the previous one only once fully written.
+func (store *Store) Save() error {
+	if store.path == "" {
+		return nil
+	}
+
+	content, err := json.MarshalIndent(store, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(store.path), 0755)
+	if err != nil {
+		return err
+	}
+
+	temporaryPath := store.path + ".tmp"
+	err = ioutil.WriteFile(temporaryPath, content, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(temporaryPath, store.path)
+}
+
+// RecordLine records the outcome of a revision along the given line of a game.
+func (store *Store) RecordLine(gameKey string, totalLines int, line string, completed bool, mistakes int) {
+	gameProgress := store.gameProgress(gameKey)
+	gameProgress.TotalLines = totalLines
+
+	outcome, found := gameProgress.Lines[line]
+	if !found {
+		outcome = &LineOutcome{}
+		gameProgress.Lines[line] = outcome
+	}
+	outcome.Completed = outcome.Completed || completed
+	outcome.Attempts++
+	outcome.Mistakes += mistakes
+	outcome.LastPlayed = time.Now()
+}
+
+// gameProgress returns the progress of a game, creating it if needed.
+func (store *Store) gameProgress(gameKey string) *GameProgress {
+	gameProgress, found := store.Games[gameKey]
+	if !found {
+		gameProgress = &GameProgress{Lines: map[string]*LineOutcome{}}
+		store.Games[gameKey] = gameProgress
+	}
+	return gameProgress
+}
+
+// CoveredLines returns the lines of a game whose end has been reached.
+func (store *Store) CoveredLines(gameKey string) map[string]bool {
+	coveredLines := map[string]bool{}
+	gameProgress, found := store.Games[gameKey]
+	if !found {
+		return coveredLines
+	}
+
+	for line, outcome := range gameProgress.Lines {
+		if outcome.Completed {
+			coveredLines[line] = true
+		}
+	}
+	return coveredLines
+}
+
+// Coverage returns the count of covered lines of a game, and its count of lines.
+// Both are 0 for a game never revised.
+func (store *Store) Coverage(gameKey string) (coveredLines int, totalLines int) {
+	gameProgress, found := store.Games[gameKey]
+	if !found {
+		return 0, 0
+	}
+	return len(store.CoveredLines(gameKey)), gameProgress.TotalLines
+}
@@ -0,0 +1,90 @@
+package progress
+
+import (
+	"math"
+	"time"
+)
+
+// initialEaseFactor and minimumEaseFactor are the bounds of the SM-2 ease factor.
+const (
+	initialEaseFactor = 2.5
+	minimumEaseFactor = 1.3
+)
+
+// Schedule holds the SM-2 spaced repetition state of a game.
+type Schedule struct {
+	// Repetitions is the count of successful reviews in a row.
+	Repetitions int `json:"repetitions"`
+
+	// EaseFactor makes the intervals grow faster for easy games.
+	EaseFactor float64 `json:"easeFactor"`
+
+	// IntervalDays is the count of days between the last review and the next one.
+	IntervalDays int `json:"intervalDays"`
+
+	// Due is the start of the day of the next review, zero for a game never reviewed.
+	Due time.Time `json:"due"`
+}
+
+// QualityFromMistakes grades a completed revision, from 5 for a perfect one,
+// down to 2 which counts as a failure.
+func QualityFromMistakes(mistakes int) int {
+	switch {
+	case mistakes == 0:
+		return 5
+	case mistakes == 1:
+		return 4
+	case mistakes == 2:
+		return 3
+	default:
+		return 2
+	}
+}
+
+// Review schedules the next review of a game, following the SM-2 algorithm,
+// given the quality of the revision done at the given time, from 0 to 5.
+// A quality under 3 is a failure, restarting the intervals from one day.
+func (store *Store) Review(gameKey string, quality int, now time.Time) {
+	schedule := &store.gameProgress(gameKey).Schedule
+	if schedule.EaseFactor == 0 {
+		schedule.EaseFactor = initialEaseFactor
+	}
+
+	if quality >= 3 {
+		switch schedule.Repetitions {
+		case 0:
+			schedule.IntervalDays = 1
+		case 1:
+			schedule.IntervalDays = 6
+		default:
+			schedule.IntervalDays = int(math.Round(float64(schedule.IntervalDays) * schedule.EaseFactor))
+		}
+		schedule.Repetitions++
+	} else {
+		schedule.Repetitions = 0
+		schedule.IntervalDays = 1
+	}
+
+	qualityGap := float64(5 - quality)
+	schedule.EaseFactor += 0.1 - qualityGap*(0.08+qualityGap*0.02)
+	if schedule.EaseFactor < minimumEaseFactor {
+		schedule.EaseFactor = minimumEaseFactor
+	}
+
+	schedule.Due = startOfDay(now).AddDate(0, 0, schedule.IntervalDays)
+}
+
+// IsDue says whether a game has been reviewed, and its next review is planned
+// on or before the day of the given time.
+func (store *Store) IsDue(gameKey string, now time.Time) bool {
+	gameProgress, found := store.Games[gameKey]
+	if !found || gameProgress.Schedule.Due.IsZero() {
+		return false
+	}
+	return !now.Before(gameProgress.Schedule.Due)
+}
+
+func startOfDay(moment time.Time) time.Time {
+	year, month, day := moment.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, moment.Location())
+}
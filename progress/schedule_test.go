@@ -0,0 +1,92 @@
+package progress
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestReviewFollowsSM2(t *testing.T) {
+	now := time.Date(2021, time.March, 10, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		qualities []int
+
+		repetitions  int
+		intervalDays int
+		easeFactor   float64
+	}{
+		{name: "first success", qualities: []int{5}, repetitions: 1, intervalDays: 1, easeFactor: 2.6},
+		{name: "second success", qualities: []int{5, 5}, repetitions: 2, intervalDays: 6, easeFactor: 2.7},
+		{name: "third success", qualities: []int{5, 5, 5}, repetitions: 3, intervalDays: 16, easeFactor: 2.8},
+		{name: "hard success", qualities: []int{3}, repetitions: 1, intervalDays: 1, easeFactor: 2.36},
+		{name: "failure", qualities: []int{5, 5, 2}, repetitions: 0, intervalDays: 1, easeFactor: 2.38},
+		{name: "success after failure", qualities: []int{5, 5, 2, 4}, repetitions: 1, intervalDays: 1, easeFactor: 2.38},
+		{name: "minimum ease factor", qualities: []int{0, 0, 0, 0}, repetitions: 0, intervalDays: 1, easeFactor: 1.3},
+	}
+
+	for _, test := range tests {
+		store := NewStore("")
+		for _, quality := range test.qualities {
+			store.Review("game", quality, now)
+		}
+
+		schedule := store.Games["game"].Schedule
+		if schedule.Repetitions != test.repetitions || schedule.IntervalDays != test.intervalDays ||
+			math.Abs(schedule.EaseFactor-test.easeFactor) > 1e-9 {
+			t.Errorf("%s: got %d repetitions, %d days and an ease factor of %g, expected %d, %d and %g",
+				test.name, schedule.Repetitions, schedule.IntervalDays, schedule.EaseFactor,
+				test.repetitions, test.intervalDays, test.easeFactor)
+		}
+
+		expectedDue := time.Date(2021, time.March, 10+test.intervalDays, 0, 0, 0, 0, time.UTC)
+		if !schedule.Due.Equal(expectedDue) {
+			t.Errorf("%s: got the due date %v, expected %v", test.name, schedule.Due, expectedDue)
+		}
+	}
+}
+
+func TestIsDueFromTheStartOfTheDueDay(t *testing.T) {
+	reviewTime := time.Date(2021, time.March, 10, 15, 30, 0, 0, time.UTC)
+	store := NewStore("")
+	store.Review("game", 5, reviewTime)
+
+	tests := []struct {
+		now time.Time
+		due bool
+	}{
+		{now: reviewTime, due: false},
+		{now: time.Date(2021, time.March, 10, 23, 59, 0, 0, time.UTC), due: false},
+		{now: time.Date(2021, time.March, 11, 0, 0, 0, 0, time.UTC), due: true},
+		{now: time.Date(2021, time.April, 1, 12, 0, 0, 0, time.UTC), due: true},
+	}
+
+	for _, test := range tests {
+		if due := store.IsDue("game", test.now); due != test.due {
+			t.Errorf("at %v: got due %v, expected %v", test.now, due, test.due)
+		}
+	}
+	if store.IsDue("unknown game", reviewTime) {
+		t.Error("a game never reviewed should not be due")
+	}
+}
+
+func TestQualityFromMistakes(t *testing.T) {
+	tests := []struct {
+		mistakes int
+		quality  int
+	}{
+		{mistakes: 0, quality: 5},
+		{mistakes: 1, quality: 4},
+		{mistakes: 2, quality: 3},
+		{mistakes: 3, quality: 2},
+		{mistakes: 10, quality: 2},
+	}
+
+	for _, test := range tests {
+		if quality := QualityFromMistakes(test.mistakes); quality != test.quality {
+			t.Errorf("%d mistakes: got the quality %d, expected %d", test.mistakes, quality, test.quality)
+		}
+	}
+}
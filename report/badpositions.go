@@ -0,0 +1,71 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+	"github.com/gookit/ini/v2"
+	"github.com/notnil/chess"
+
+	"github.com/loloof64/chess-pgn-reviser-fyne/progress"
+)
+
+// ShowBadPositions opens a window listing the given bad positions, and calls
+// onDrill with the position clicked by the user, closing the window.
+func ShowBadPositions(positions []progress.BadPosition, onDrill func(position progress.BadPosition)) {
+	positionsWindow := fyne.CurrentApp().NewWindow(ini.String("badPositions.windowTitle"))
+
+	positionsList := widget.NewList(
+		func() int {
+			return len(positions)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(itemIndex widget.ListItemID, item fyne.CanvasObject) {
+			item.(*widget.Label).SetText(describeBadPosition(positions[itemIndex]))
+		},
+	)
+	positionsList.OnSelected = func(itemIndex widget.ListItemID) {
+		positionsWindow.Close()
+		onDrill(positions[itemIndex])
+	}
+
+	var content fyne.CanvasObject = positionsList
+	if len(positions) == 0 {
+		content = widget.NewLabel(ini.String("badPositions.noPositions"))
+	}
+
+	positionsWindow.SetContent(content)
+	positionsWindow.Resize(fyne.NewSize(450, 500))
+	positionsWindow.Show()
+}
+
+func describeBadPosition(position progress.BadPosition) string {
+	fenParts := strings.Split(position.Fen, " ")
+	moveNumber := fenParts[len(fenParts)-1]
+	numberMarker := moveNumber + "."
+	if len(fenParts) > 1 && fenParts[1] == "b" {
+		numberMarker = moveNumber + "..."
+	}
+
+	return fmt.Sprintf("%s %s : %s (%s)", numberMarker, sanOf(position.Fen, position.PlayedMove),
+		ini.String("report."+position.Classification), position.Recorded.Format("2006-01-02"))
+}
+
+// sanOf returns the given move, in UCI notation, in Standard Algebraic
+// Notation, or unchanged if it cannot be decoded in the given position.
+func sanOf(fen string, uciMove string) string {
+	fenUpdater, err := chess.FEN(fen)
+	if err != nil {
+		return uciMove
+	}
+	position := chess.NewGame(fenUpdater).Position()
+	move, err := chess.UCINotation{}.Decode(position, uciMove)
+	if err != nil {
+		return uciMove
+	}
+	return chess.AlgebraicNotation{}.Encode(position, move)
+}
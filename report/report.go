@@ -0,0 +1,205 @@
+package report
+
+import (
+	"context"
+	"time"
+
+	"github.com/notnil/chess"
+
+	"github.com/loloof64/chess-pgn-reviser-fyne/engine"
+	"github.com/loloof64/chess-pgn-reviser-fyne/pgnLoader"
+)
+
+// Classification grades a move by its centipawn loss.
+type Classification int
+
+const (
+	// Best is a move as good as the best one.
+	Best Classification = iota
+
+	// Good is a move losing a little.
+	Good
+
+	// Inaccuracy is a move losing up to one pawn.
+	Inaccuracy
+
+	// Mistake is a move losing up to three pawns.
+	Mistake
+
+	// Blunder is a move losing more than three pawns.
+	Blunder
+)
+
+// classificationsNames are the names of the classifications, in order.
+var classificationsNames = []string{"best", "good", "inaccuracy", "mistake", "blunder"}
+
+// Name returns the name of the classification, which is also the key of its
+// label in the report locale section.
+func (classification Classification) Name() string {
+	return classificationsNames[classification]
+}
+
+// Classify grades a move by its centipawn loss.
+func Classify(centipawnLoss int) Classification {
+	switch {
+	case centipawnLoss <= 10:
+		return Best
+	case centipawnLoss <= 50:
+		return Good
+	case centipawnLoss <= 100:
+		return Inaccuracy
+	case centipawnLoss <= 300:
+		return Mistake
+	default:
+		return Blunder
+	}
+}
+
+// Evaluator evaluates positions, as engine.Engine does.
+type Evaluator interface {
+	Evaluate(fen string, searchTime time.Duration) (engine.Info, error)
+}
+
+// MoveReport compares a move with the engine evaluation.
+type MoveReport struct {
+	// Node is the move in the revised game.
+	Node *pgnLoader.MoveNode
+
+	// San is the move in Standard Algebraic Notation.
+	San string
+
+	// BestSan is the best move found by the engine, empty if there is none.
+	BestSan string
+
+	// BestMove is the best move found by the engine, nil if there is none.
+	BestMove *chess.Move
+
+	CentipawnLoss  int
+	Classification Classification
+}
+
+// Build evaluates the moves of the given side in the given path, searching each
+// position during searchTime. onProgress, if not nil, receives the count of
+// evaluated positions and the count of positions to evaluate. Building stops
+// with the context error if ctx is cancelled.
+func Build(ctx context.Context, evaluator Evaluator, path []*pgnLoader.MoveNode, side chess.Color,
+	searchTime time.Duration, onProgress func(done int, total int)) ([]MoveReport, error) {
+	sideMoves := []*pgnLoader.MoveNode{}
+	for _, node := range path {
+		if node.Parent.Position.Turn() == side {
+			sideMoves = append(sideMoves, node)
+		}
+	}
+
+	evaluations := map[string]engine.Info{}
+	total := 2 * len(sideMoves)
+	done := 0
+	evaluate := func(position *chess.Position) (engine.Info, error) {
+		if err := ctx.Err(); err != nil {
+			return engine.Info{}, err
+		}
+		fen := position.String()
+		info, found := evaluations[fen]
+		if !found {
+			var err error
+			info, err = evaluatePosition(evaluator, position, searchTime)
+			if err != nil {
+				return engine.Info{}, err
+			}
+			evaluations[fen] = info
+		}
+		done++
+		if onProgress != nil {
+			onProgress(done, total)
+		}
+		return info, nil
+	}
+
+	reports := []MoveReport{}
+	for _, node := range sideMoves {
+		before, err := evaluate(node.Parent.Position)
+		if err != nil {
+			return nil, err
+		}
+		after, err := evaluate(node.Position)
+		if err != nil {
+			return nil, err
+		}
+
+		centipawnLoss := centipawnLoss(before, after)
+		report := MoveReport{
+			Node:           node,
+			San:            chess.AlgebraicNotation{}.Encode(node.Parent.Position, node.Move),
+			CentipawnLoss:  centipawnLoss,
+			Classification: Classify(centipawnLoss),
+		}
+		if len(before.PvMoves) > 0 {
+			report.BestMove = before.PvMoves[0]
+			report.BestSan = before.Pv[0]
+			if report.BestMove.String() == node.Move.String() {
+				report.Classification = Best
+			}
+		}
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+// GoodMoves evaluates each legal move of the given position, in
+// Forsyth-Edwards Notation, searching each position during searchTime. It
+// returns the moves classified Best or Good, in UCI notation, with the best
+// move of the engine. It stops with the context error if ctx is cancelled.
+func GoodMoves(ctx context.Context, evaluator Evaluator, fen string, searchTime time.Duration) (map[string]bool, error) {
+	fenUpdater, err := chess.FEN(fen)
+	if err != nil {
+		return nil, err
+	}
+	position := chess.NewGame(fenUpdater).Position()
+
+	before, err := evaluatePosition(evaluator, position, searchTime)
+	if err != nil {
+		return nil, err
+	}
+	goodMoves := map[string]bool{}
+	if len(before.PvMoves) > 0 {
+		goodMoves[before.PvMoves[0].String()] = true
+	}
+
+	for _, move := range position.ValidMoves() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		after, err := evaluatePosition(evaluator, position.Update(move), searchTime)
+		if err != nil {
+			return nil, err
+		}
+		if Classify(centipawnLoss(before, after)) <= Good {
+			goodMoves[move.String()] = true
+		}
+	}
+
+	return goodMoves, nil
+}
+
+// centipawnLoss compares the evaluations before and after a move, the one
+// after the move being given for the opponent.
+func centipawnLoss(before engine.Info, after engine.Info) int {
+	loss := before.Centipawns() + after.Centipawns()
+	if loss < 0 {
+		return 0
+	}
+	return loss
+}
+
+// evaluatePosition asks the engine, unless the game is over in the position.
+func evaluatePosition(evaluator Evaluator, position *chess.Position, searchTime time.Duration) (engine.Info, error) {
+	switch position.Status() {
+	case chess.Checkmate:
+		// Mated in 0 moves.
+		return engine.Info{Score: -engine.MateCentipawns}, nil
+	case chess.Stalemate:
+		return engine.Info{}, nil
+	}
+	return evaluator.Evaluate(position.String(), searchTime)
+}
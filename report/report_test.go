@@ -0,0 +1,267 @@
+package report
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/notnil/chess"
+
+	"github.com/loloof64/chess-pgn-reviser-fyne/engine"
+	"github.com/loloof64/chess-pgn-reviser-fyne/pgnLoader"
+)
+
+// scriptedEvaluator answers the evaluations given for each position, counting the requests.
+type scriptedEvaluator struct {
+	infos    map[string]engine.Info
+	requests map[string]int
+}
+
+func newScriptedEvaluator() *scriptedEvaluator {
+	return &scriptedEvaluator{infos: map[string]engine.Info{}, requests: map[string]int{}}
+}
+
+func (evaluator *scriptedEvaluator) Evaluate(fen string, searchTime time.Duration) (engine.Info, error) {
+	evaluator.requests[fen]++
+	return evaluator.infos[fen], nil
+}
+
+// linePath plays the given moves, in Standard Algebraic Notation, from the given position.
+func linePath(t *testing.T, startFen string, moves ...string) []*pgnLoader.MoveNode {
+	fen, err := chess.FEN(startFen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	node := &pgnLoader.MoveNode{Position: chess.NewGame(fen).Position()}
+
+	path := []*pgnLoader.MoveNode{}
+	for _, san := range moves {
+		move, err := chess.AlgebraicNotation{}.Decode(node.Position, san)
+		if err != nil {
+			t.Fatalf("%s: %v", san, err)
+		}
+		child := &pgnLoader.MoveNode{San: san, Move: move, Position: node.Position.Update(move), Parent: node}
+		node.Children = append(node.Children, child)
+		path = append(path, child)
+		node = child
+	}
+	return path
+}
+
+const startFen = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		centipawnLoss  int
+		classification Classification
+	}{
+		{centipawnLoss: 0, classification: Best},
+		{centipawnLoss: 10, classification: Best},
+		{centipawnLoss: 11, classification: Good},
+		{centipawnLoss: 50, classification: Good},
+		{centipawnLoss: 51, classification: Inaccuracy},
+		{centipawnLoss: 100, classification: Inaccuracy},
+		{centipawnLoss: 101, classification: Mistake},
+		{centipawnLoss: 300, classification: Mistake},
+		{centipawnLoss: 301, classification: Blunder},
+	}
+
+	for _, test := range tests {
+		if classification := Classify(test.centipawnLoss); classification != test.classification {
+			t.Errorf("%d: got %s, expected %s", test.centipawnLoss, classification.Name(), test.classification.Name())
+		}
+	}
+}
+
+func TestBuildComparesTheEvaluationsAroundTheMove(t *testing.T) {
+	path := linePath(t, startFen, "e4")
+	before, after := path[0].Parent.Position.String(), path[0].Position.String()
+	bestMove, err := chess.UCINotation{}.Decode(path[0].Parent.Position, "d2d4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	playedMove := path[0].Move
+
+	tests := []struct {
+		name   string
+		before engine.Info
+		after  engine.Info
+
+		centipawnLoss  int
+		classification Classification
+	}{
+		{name: "kept evaluation", before: engine.Info{Score: 30}, after: engine.Info{Score: -30},
+			centipawnLoss: 0, classification: Best},
+		{name: "good move", before: engine.Info{Score: 30}, after: engine.Info{Score: 10},
+			centipawnLoss: 40, classification: Good},
+		{name: "inaccuracy", before: engine.Info{Score: 30}, after: engine.Info{Score: 70},
+			centipawnLoss: 100, classification: Inaccuracy},
+		{name: "mistake", before: engine.Info{Score: 30}, after: engine.Info{Score: 270},
+			centipawnLoss: 300, classification: Mistake},
+		{name: "blunder", before: engine.Info{Score: 30}, after: engine.Info{Score: 271},
+			centipawnLoss: 301, classification: Blunder},
+		{name: "better than expected", before: engine.Info{Score: 30}, after: engine.Info{Score: -80},
+			centipawnLoss: 0, classification: Best},
+		{name: "missed mate", before: engine.Info{Mate: 2}, after: engine.Info{Score: 0},
+			centipawnLoss: engine.MateCentipawns - 2, classification: Blunder},
+		{name: "engine best move", before: engine.Info{Score: 30, Pv: []string{"e4"}, PvMoves: []*chess.Move{playedMove}},
+			after: engine.Info{Score: 100}, centipawnLoss: 130, classification: Best},
+		{name: "other best move", before: engine.Info{Score: 30, Pv: []string{"d4"}, PvMoves: []*chess.Move{bestMove}},
+			after: engine.Info{Score: 100}, centipawnLoss: 130, classification: Mistake},
+	}
+
+	for _, test := range tests {
+		evaluator := newScriptedEvaluator()
+		evaluator.infos[before] = test.before
+		evaluator.infos[after] = test.after
+
+		reports, err := Build(context.Background(), evaluator, path, chess.White, time.Millisecond, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if len(reports) != 1 {
+			t.Fatalf("%s: got %d reports, expected 1", test.name, len(reports))
+		}
+		moveReport := reports[0]
+		if moveReport.CentipawnLoss != test.centipawnLoss || moveReport.Classification != test.classification {
+			t.Errorf("%s: got a loss of %d, %s, expected %d, %s", test.name, moveReport.CentipawnLoss,
+				moveReport.Classification.Name(), test.centipawnLoss, test.classification.Name())
+		}
+		if moveReport.San != "e4" {
+			t.Errorf("%s: got the move %s, expected e4", test.name, moveReport.San)
+		}
+		if len(test.before.Pv) > 0 && moveReport.BestSan != test.before.Pv[0] {
+			t.Errorf("%s: got the best move %s, expected %s", test.name, moveReport.BestSan, test.before.Pv[0])
+		}
+	}
+}
+
+func TestBuildDoesNotAskTheEngineForFinishedGames(t *testing.T) {
+	tests := []struct {
+		name     string
+		startFen string
+		move     string
+		before   engine.Info
+
+		centipawnLoss  int
+		classification Classification
+	}{
+		{name: "mate in 1 followed by checkmate",
+			startFen: "rnbqkbnr/pppp1ppp/8/4p3/6P1/5P2/PPPPP2P/RNBQKBNR b KQkq g3 0 2", move: "Qh4#",
+			before: engine.Info{Mate: 1}, centipawnLoss: 0, classification: Best},
+		{name: "stalemate of a won position",
+			startFen: "k7/8/2Q5/8/8/8/8/7K w - - 0 1", move: "Qb6",
+			before: engine.Info{Mate: 2}, centipawnLoss: engine.MateCentipawns - 2, classification: Blunder},
+	}
+
+	for _, test := range tests {
+		path := linePath(t, test.startFen, test.move)
+		side := path[0].Parent.Position.Turn()
+		evaluator := newScriptedEvaluator()
+		evaluator.infos[path[0].Parent.Position.String()] = test.before
+
+		reports, err := Build(context.Background(), evaluator, path, side, time.Millisecond, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if reports[0].CentipawnLoss != test.centipawnLoss || reports[0].Classification != test.classification {
+			t.Errorf("%s: got a loss of %d, %s, expected %d, %s", test.name, reports[0].CentipawnLoss,
+				reports[0].Classification.Name(), test.centipawnLoss, test.classification.Name())
+		}
+		if requests := evaluator.requests[path[0].Position.String()]; requests != 0 {
+			t.Errorf("%s: the engine has been asked %d times for the finished game", test.name, requests)
+		}
+	}
+}
+
+func TestBuildEvaluatesOnlyTheMovesOfTheSide(t *testing.T) {
+	path := linePath(t, startFen, "e4", "e5", "Nf3", "Nc6")
+	evaluator := newScriptedEvaluator()
+
+	progressCalls := 0
+	reports, err := Build(context.Background(), evaluator, path, chess.Black, time.Millisecond, func(done int, total int) {
+		progressCalls++
+		if total != 4 || done != progressCalls {
+			t.Errorf("got the progress %d/%d, expected %d/4", done, total, progressCalls)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 2 || reports[0].San != "e5" || reports[1].San != "Nc6" {
+		t.Fatalf("got %d reports, expected the ones of e5 and Nc6", len(reports))
+	}
+	if progressCalls != 4 {
+		t.Errorf("got %d progress calls, expected 4", progressCalls)
+	}
+}
+
+func TestBuildEvaluatesEachPositionOnce(t *testing.T) {
+	path := linePath(t, startFen, "e4")
+	// The same move, twice, shares both of its positions.
+	path = append(path, path[0])
+	evaluator := newScriptedEvaluator()
+
+	_, err := Build(context.Background(), evaluator, path, chess.White, time.Millisecond, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for fen, requests := range evaluator.requests {
+		if requests != 1 {
+			t.Errorf("%s has been evaluated %d times, expected once", fen, requests)
+		}
+	}
+	if len(evaluator.requests) != 2 {
+		t.Errorf("got %d evaluated positions, expected 2", len(evaluator.requests))
+	}
+}
+
+func TestBuildStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := Build(ctx, newScriptedEvaluator(), linePath(t, startFen, "e4"), chess.White, time.Millisecond, nil)
+	if err != context.Canceled {
+		t.Errorf("got the error %v, expected %v", err, context.Canceled)
+	}
+}
+
+func TestGoodMovesKeepsTheMovesLosingLittle(t *testing.T) {
+	position := linePath(t, startFen, "e4")[0].Parent.Position
+	after := func(uci string) string {
+		move, err := chess.UCINotation{}.Decode(position, uci)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return position.Update(move).String()
+	}
+	bestMove, err := chess.UCINotation{}.Decode(position, "d2d4")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	evaluator := newScriptedEvaluator()
+	evaluator.infos[startFen] = engine.Info{Score: 30, Pv: []string{"d4"}, PvMoves: []*chess.Move{bestMove}}
+	// The other moves keep the evaluation.
+	for _, move := range position.ValidMoves() {
+		evaluator.infos[after(move.String())] = engine.Info{Score: -30}
+	}
+	evaluator.infos[after("d2d4")] = engine.Info{Score: 200}
+	evaluator.infos[after("g1f3")] = engine.Info{Score: 20}
+	evaluator.infos[after("c2c4")] = engine.Info{Score: 21}
+	evaluator.infos[after("f2f3")] = engine.Info{Score: 100}
+
+	goodMoves, err := GoodMoves(context.Background(), evaluator, startFen, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]bool{"d2d4": true, "g1f3": true, "c2c4": false, "f2f3": false, "e2e4": true}
+	for move, good := range expected {
+		if goodMoves[move] != good {
+			t.Errorf("%s: got %v, expected %v", move, goodMoves[move], good)
+		}
+	}
+	if len(goodMoves) != len(position.ValidMoves())-2 {
+		t.Errorf("got %d good moves, expected %d", len(goodMoves), len(position.ValidMoves())-2)
+	}
+}
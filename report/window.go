@@ -0,0 +1,63 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/gookit/ini/v2"
+
+	"github.com/loloof64/chess-pgn-reviser-fyne/engine"
+)
+
+// ShowReport opens a window listing the given move reports, and calls
+// onMoveSelected with the report clicked by the user.
+func ShowReport(reports []MoveReport, onMoveSelected func(moveReport MoveReport)) {
+	reportWindow := fyne.CurrentApp().NewWindow(ini.String("report.windowTitle"))
+
+	reportsList := widget.NewList(
+		func() int {
+			return len(reports)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(itemIndex widget.ListItemID, item fyne.CanvasObject) {
+			item.(*widget.Label).SetText(describe(reports[itemIndex]))
+		},
+	)
+	reportsList.OnSelected = func(itemIndex widget.ListItemID) {
+		onMoveSelected(reports[itemIndex])
+	}
+
+	var content fyne.CanvasObject = reportsList
+	if len(reports) == 0 {
+		content = widget.NewLabel(ini.String("report.noMoves"))
+	}
+
+	reportWindow.SetContent(content)
+	reportWindow.Resize(fyne.NewSize(450, 500))
+	reportWindow.Show()
+}
+
+func describe(moveReport MoveReport) string {
+	fenParts := strings.Split(moveReport.Node.Parent.Position.String(), " ")
+	moveNumber := fenParts[len(fenParts)-1]
+	numberMarker := moveNumber + "."
+	if !moveReport.Node.IsWhiteMove() {
+		numberMarker = moveNumber + "..."
+	}
+
+	description := fmt.Sprintf("%s %s : %s", numberMarker, moveReport.San,
+		ini.String("report."+moveReport.Classification.Name()))
+	// Losses involving mates are too big to be meaningful.
+	if moveReport.CentipawnLoss > 0 && moveReport.CentipawnLoss < engine.MateCentipawns/2 {
+		description += fmt.Sprintf(" (%.2f)", -float64(moveReport.CentipawnLoss)/100)
+	}
+	if moveReport.Classification != Best && moveReport.BestSan != "" {
+		description += fmt.Sprintf(" - %s %s", ini.String("report.bestMove"), moveReport.BestSan)
+	}
+	return description
+}
@@ -0,0 +1,169 @@
+package revision
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/loloof64/chess-pgn-reviser-fyne/pgnLoader"
+)
+
+// MoveStatus tells how the user found a move.
+type MoveStatus string
+
+const (
+	// FoundFirstTry is the status of the moves found at the first try, without hint.
+	FoundFirstTry MoveStatus = "firstTry"
+
+	// FoundAfterMistakes is the status of the moves found after wrong attempts, without hint.
+	FoundAfterMistakes MoveStatus = "afterMistakes"
+
+	// FoundWithHints is the status of the moves found after some hints.
+	FoundWithHints MoveStatus = "withHints"
+
+	// Failed is the status of the moves played for the user, by a hint or once the time has run out.
+	Failed MoveStatus = "failed"
+)
+
+// MoveResult is the outcome of a move of the user.
+type MoveResult struct {
+	// Ply is the count of half moves from the start position of the game, the
+	// moves skipped by the session and this one included.
+	Ply int
+
+	// MoveNumber is the number of the move, as written before it in a PGN.
+	MoveNumber int
+	WhiteMove  bool
+	San        string
+
+	// Fen is the position after the move.
+	Fen string
+
+	Status        MoveStatus
+	WrongAttempts int
+
+	// Hints counts the hints asked for the move, peeks at the hidden pieces included.
+	Hints int
+
+	// TimedOut says whether the time to play the move has run out, the move being played for the user.
+	TimedOut bool
+
+	// Duration is the time the user took to play the move.
+	Duration time.Duration
+}
+
+// newMoveResult describes the move of the given node, played at the given
+// time at the end of the given turn.
+func newMoveResult(node *pgnLoader.MoveNode, ply int, moveTurn turn, end time.Time) MoveResult {
+	status := FoundFirstTry
+	switch {
+	case moveTurn.hintLevel == PlayedMoveHint || moveTurn.timedOut:
+		status = Failed
+	case moveTurn.hintLevel > NoHint || moveTurn.peeks > 0:
+		status = FoundWithHints
+	case moveTurn.wrongAttempts > 0:
+		status = FoundAfterMistakes
+	}
+
+	fenParts := strings.Split(node.Parent.Position.String(), " ")
+	moveNumber, _ := strconv.Atoi(fenParts[len(fenParts)-1])
+
+	return MoveResult{
+		Ply:           ply,
+		MoveNumber:    moveNumber,
+		WhiteMove:     node.IsWhiteMove(),
+		San:           node.San,
+		Fen:           node.Position.String(),
+		Status:        status,
+		WrongAttempts: moveTurn.wrongAttempts,
+		Hints:         int(moveTurn.hintLevel) + moveTurn.peeks,
+		TimedOut:      moveTurn.timedOut,
+		Duration:      end.Sub(moveTurn.start),
+	}
+}
+
+// Results returns the outcomes of the moves played by the user so far, in order.
+func (session *Session) Results() []MoveResult {
+	return append([]MoveResult(nil), session.results...)
+}
+
+// resultsHeader names the columns of the CSV export of the results.
+var resultsHeader = []string{"ply", "moveNumber", "side", "move", "status", "wrongAttempts", "hints", "timedOut", "seconds", "fen"}
+
+// WriteResultsCSV writes the results as CSV, with a header line.
+func WriteResultsCSV(writer io.Writer, results []MoveResult) error {
+	csvWriter := csv.NewWriter(writer)
+	err := csvWriter.Write(resultsHeader)
+	if err != nil {
+		return err
+	}
+
+	for _, result := range results {
+		err = csvWriter.Write([]string{
+			strconv.Itoa(result.Ply),
+			strconv.Itoa(result.MoveNumber),
+			sideName(result.WhiteMove),
+			result.San,
+			string(result.Status),
+			strconv.Itoa(result.WrongAttempts),
+			strconv.Itoa(result.Hints),
+			strconv.FormatBool(result.TimedOut),
+			fmt.Sprintf("%.1f", result.Duration.Seconds()),
+			result.Fen,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// jsonMoveResult is a move result as exported in JSON.
+type jsonMoveResult struct {
+	Ply           int        `json:"ply"`
+	MoveNumber    int        `json:"moveNumber"`
+	Side          string     `json:"side"`
+	Move          string     `json:"move"`
+	Status        MoveStatus `json:"status"`
+	WrongAttempts int        `json:"wrongAttempts"`
+	Hints         int        `json:"hints"`
+	TimedOut      bool       `json:"timedOut"`
+	Seconds       float64    `json:"seconds"`
+	Fen           string     `json:"fen"`
+}
+
+// WriteResultsJSON writes the results as an indented JSON array.
+func WriteResultsJSON(writer io.Writer, results []MoveResult) error {
+	exported := make([]jsonMoveResult, 0, len(results))
+	for _, result := range results {
+		exported = append(exported, jsonMoveResult{
+			Ply:           result.Ply,
+			MoveNumber:    result.MoveNumber,
+			Side:          sideName(result.WhiteMove),
+			Move:          result.San,
+			Status:        result.Status,
+			WrongAttempts: result.WrongAttempts,
+			Hints:         result.Hints,
+			TimedOut:      result.TimedOut,
+			Seconds:       result.Duration.Round(100 * time.Millisecond).Seconds(),
+			Fen:           result.Fen,
+		})
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(exported)
+}
+
+func sideName(whiteMove bool) string {
+	if whiteMove {
+		return "white"
+	}
+	return "black"
+}
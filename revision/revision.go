@@ -0,0 +1,293 @@
+package revision
+
+import (
+	"strings"
+	"time"
+
+	"github.com/notnil/chess"
+
+	"github.com/loloof64/chess-pgn-reviser-fyne/pgnLoader"
+)
+
+// HintLevel tells how much of the expected move the hints have revealed.
+type HintLevel int
+
+const (
+	// NoHint is the level until the user asks for a hint.
+	NoHint HintLevel = iota
+
+	// OriginCellHint reveals the cell of the piece to move.
+	OriginCellHint
+
+	// MoveArrowHint reveals the whole move.
+	MoveArrowHint
+
+	// PlayedMoveHint plays the move for the user, which counts as a mistake.
+	PlayedMoveHint
+)
+
+// Session holds the moves tree of a revision game, and the progress of the
+// user along it. Any move of the tree is accepted, variations included, and
+// the session then follows the chosen branch.
+type Session struct {
+	game         *pgnLoader.Game
+	trainedSide  chess.Color
+	coveredLines map[string]bool
+	// startNode is the position the session started from, after the skipped moves.
+	startNode   *pgnLoader.MoveNode
+	currentNode *pgnLoader.MoveNode
+	mistakes    int
+	hints       int
+	turn        turn
+	results     []MoveResult
+}
+
+// turn holds what happened since the last move.
+type turn struct {
+	// start is the time of the last move, from which the next one is timed.
+	start     time.Time
+	hintLevel HintLevel
+
+	// wrongAttempts counts the wrong moves tried by the user.
+	wrongAttempts int
+	// peeks counts the looks at the hidden pieces.
+	peeks    int
+	timedOut bool
+}
+
+// NewSession creates a revision session from the moves tree of a parsed game.
+// The user finds the moves of the trained side, the other side's moves being
+// played by the computer, which prefers the lines not in coveredLines. The
+// session starts after the first startPly moves of the main line, which are
+// considered already played.
+func NewSession(game *pgnLoader.Game, trainedSide chess.Color, coveredLines map[string]bool, startPly int) *Session {
+	startNode := game.Root
+	for ply := 0; ply < startPly && len(startNode.Children) > 0; ply++ {
+		startNode = startNode.MainChild()
+	}
+
+	return &Session{
+		game:         game,
+		trainedSide:  trainedSide,
+		coveredLines: coveredLines,
+		startNode:    startNode,
+		currentNode:  startNode,
+		turn:         turn{start: time.Now()},
+	}
+}
+
+// Game returns the revised game.
+func (session *Session) Game() *pgnLoader.Game {
+	return session.game
+}
+
+// StartPosition returns the start position of the game, in Forsyth-Edwards Notation.
+func (session *Session) StartPosition() string {
+	return session.game.Root.Position.String()
+}
+
+// CurrentPosition returns the position reached so far, in Forsyth-Edwards
+// Notation, which is the first position to play from when the session starts.
+func (session *Session) CurrentPosition() string {
+	return session.currentNode.Position.String()
+}
+
+// TrainedSide returns the side whose moves the user has to find.
+func (session *Session) TrainedSide() chess.Color {
+	return session.trainedSide
+}
+
+// IsUserTurn says whether the next move belongs to the trained side.
+func (session *Session) IsUserTurn() bool {
+	return session.currentNode.Position.Turn() == session.trainedSide
+}
+
+// ExpectedMove returns the next move to play: the first one leading to a line
+// not covered yet, otherwise the one of the current line. It returns nil if
+// the end of the line has been reached.
+func (session *Session) ExpectedMove() *chess.Move {
+	if session.Finished() {
+		return nil
+	}
+
+	currentLine := session.Line()
+	for _, child := range session.currentNode.Children {
+		if session.hasUncoveredLine(child, appendMove(currentLine, child)) {
+			return child.Move
+		}
+	}
+	return session.currentNode.Children[0].Move
+}
+
+// CheckMove says whether the given move is one of the moves of the tree from
+// the current position, counting a mistake otherwise.
+func (session *Session) CheckMove(move *chess.Move) bool {
+	for _, child := range session.currentNode.Children {
+		if sameMove(move, child.Move) {
+			return true
+		}
+	}
+	session.mistakes++
+	session.turn.wrongAttempts++
+	return false
+}
+
+// Advance follows the branch of the tree leading to the given position, in
+// Forsyth-Edwards Notation, recording the result of the move if it has been
+// played by the user. It returns false if no move leads to it.
+func (session *Session) Advance(positionFen string) bool {
+	for _, child := range session.currentNode.Children {
+		if child.Position.String() == positionFen {
+			now := time.Now()
+			if session.IsUserTurn() {
+				session.results = append(session.results, newMoveResult(child, len(session.fullPath())+1,
+					session.turn, now))
+			}
+
+			session.currentNode = child
+			session.turn = turn{start: now}
+			return true
+		}
+	}
+	return false
+}
+
+// NextHint reveals more of the move the user has to find, returning the
+// new hint level and the expected move. It returns NoHint and nil if it is
+// not the turn of the user.
+func (session *Session) NextHint() (HintLevel, *chess.Move) {
+	if session.Finished() || !session.IsUserTurn() {
+		return NoHint, nil
+	}
+
+	if session.turn.hintLevel < PlayedMoveHint {
+		session.turn.hintLevel++
+		session.hints++
+		if session.turn.hintLevel == PlayedMoveHint {
+			session.mistakes++
+		}
+	}
+	return session.turn.hintLevel, session.ExpectedMove()
+}
+
+// Peek counts a look of the user at the pieces hidden by a blindfold mode
+// as a hint. It returns false, counting nothing, if it is not the turn of the user.
+func (session *Session) Peek() bool {
+	if session.Finished() || !session.IsUserTurn() {
+		return false
+	}
+
+	session.turn.peeks++
+	session.hints++
+	return true
+}
+
+// TimeOut counts the move the user has to find as failed, the time to play
+// it having run out, and returns it so that it can be played for the user.
+// It returns nil if it is not the turn of the user.
+func (session *Session) TimeOut() *chess.Move {
+	if session.Finished() || !session.IsUserTurn() {
+		return nil
+	}
+
+	if !session.turn.timedOut && session.turn.hintLevel < PlayedMoveHint {
+		session.mistakes++
+	}
+	session.turn.timedOut = true
+	return session.ExpectedMove()
+}
+
+// Hints returns the count of hints asked by the user, peeks included.
+func (session *Session) Hints() int {
+	return session.hints
+}
+
+// Finished says whether the end of the followed line has been reached.
+func (session *Session) Finished() bool {
+	return len(session.currentNode.Children) == 0
+}
+
+// Mistakes returns the count of wrong moves played by the user, and of
+// moves played for the user by a hint.
+func (session *Session) Mistakes() int {
+	return session.mistakes
+}
+
+// Line returns the moves from the start position of the game, skipped moves
+// included, in UCI notation separated by spaces.
+func (session *Session) Line() string {
+	moves := []string{}
+	for _, node := range session.fullPath() {
+		moves = append(moves, node.Move.String())
+	}
+	return strings.Join(moves, " ")
+}
+
+// SkippedMoves returns the moves considered already played when the session
+// started, in order.
+func (session *Session) SkippedMoves() []*pgnLoader.MoveNode {
+	return pathBetween(session.game.Root, session.startNode)
+}
+
+// Path returns the moves played since the session started, in order.
+func (session *Session) Path() []*pgnLoader.MoveNode {
+	return pathBetween(session.startNode, session.currentNode)
+}
+
+func (session *Session) fullPath() []*pgnLoader.MoveNode {
+	return pathBetween(session.game.Root, session.currentNode)
+}
+
+// pathBetween returns the moves leading from the first node to the last one, in order.
+func pathBetween(first *pgnLoader.MoveNode, last *pgnLoader.MoveNode) []*pgnLoader.MoveNode {
+	path := []*pgnLoader.MoveNode{}
+	for node := last; node != first && node.Parent != nil; node = node.Parent {
+		path = append([]*pgnLoader.MoveNode{node}, path...)
+	}
+	return path
+}
+
+// LinesCount returns the count of lines of the game, that is of its moves
+// ending a line.
+func (session *Session) LinesCount() int {
+	return countLines(session.game.Root)
+}
+
+func countLines(node *pgnLoader.MoveNode) int {
+	if len(node.Children) == 0 {
+		return 1
+	}
+	count := 0
+	for _, child := range node.Children {
+		count += countLines(child)
+	}
+	return count
+}
+
+func (session *Session) hasUncoveredLine(node *pgnLoader.MoveNode, line string) bool {
+	if len(node.Children) == 0 {
+		return !session.coveredLines[line]
+	}
+	for _, child := range node.Children {
+		if session.hasUncoveredLine(child, appendMove(line, child)) {
+			return true
+		}
+	}
+	return false
+}
+
+func appendMove(line string, node *pgnLoader.MoveNode) string {
+	if line == "" {
+		return node.Move.String()
+	}
+	return line + " " + node.Move.String()
+}
+
+func sameMove(first *chess.Move, second *chess.Move) bool {
+	if first == nil || second == nil {
+		return false
+	}
+	return first.S1() == second.S1() &&
+		first.S2() == second.S2() &&
+		first.Promo() == second.Promo()
+}
@@ -0,0 +1,341 @@
+package revision
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/notnil/chess"
+
+	"github.com/loloof64/chess-pgn-reviser-fyne/pgnLoader"
+)
+
+// testGame has a main line and a variation of the first black move.
+const testGame = "[Event \"Test\"]\n\n1. e4 e5 (1... c5 2. Nf3) 2. Nf3 Nc6 *\n"
+
+// loadGame parses the single game of the given content, through the index of a file.
+func loadGame(t *testing.T, content string) *pgnLoader.Game {
+	path := filepath.Join(t.TempDir(), "test.pgn")
+	err := ioutil.WriteFile(path, []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err := pgnLoader.BuildIndex(context.Background(), path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	game, err := index.LoadGame(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return game
+}
+
+func newTestSession(t *testing.T, trainedSide chess.Color, coveredLines map[string]bool) *Session {
+	return NewSession(loadGame(t, testGame), trainedSide, coveredLines, 0)
+}
+
+// play checks then plays the move of the current position written in
+// Standard Algebraic Notation, as the board does.
+func play(t *testing.T, session *Session, san string) {
+	for _, child := range session.currentNode.Children {
+		if child.San != san {
+			continue
+		}
+		if session.IsUserTurn() && !session.CheckMove(child.Move) {
+			t.Fatalf("%s has been refused", san)
+		}
+		if !session.Advance(child.Position.String()) {
+			t.Fatalf("%s has not been followed", san)
+		}
+		return
+	}
+	t.Fatalf("%s is not in the tree", san)
+}
+
+// wrongMove returns a legal move of the current position which is not in the tree.
+func wrongMove(t *testing.T, session *Session) *chess.Move {
+	for _, move := range session.currentNode.Position.ValidMoves() {
+		inTree := false
+		for _, child := range session.currentNode.Children {
+			inTree = inTree || sameMove(move, child.Move)
+		}
+		if !inTree {
+			return move
+		}
+	}
+	t.Fatal("no wrong move")
+	return nil
+}
+
+func TestExpectedMovePrefersTheUncoveredLines(t *testing.T) {
+	tests := []struct {
+		name         string
+		coveredLines map[string]bool
+		expected     string
+	}{
+		{name: "nothing covered", coveredLines: map[string]bool{}, expected: "e7e5"},
+		{name: "main line covered", coveredLines: map[string]bool{"e2e4 e7e5 g1f3 b8c6": true}, expected: "c7c5"},
+		{name: "variation covered", coveredLines: map[string]bool{"e2e4 c7c5 g1f3": true}, expected: "e7e5"},
+		{name: "all covered", coveredLines: map[string]bool{"e2e4 e7e5 g1f3 b8c6": true, "e2e4 c7c5 g1f3": true},
+			expected: "e7e5"},
+	}
+
+	for _, test := range tests {
+		session := newTestSession(t, chess.White, test.coveredLines)
+		play(t, session, "e4")
+		if session.IsUserTurn() {
+			t.Fatalf("%s: black should be played by the computer", test.name)
+		}
+		if move := session.ExpectedMove(); move.String() != test.expected {
+			t.Errorf("%s: got %s, expected %s", test.name, move, test.expected)
+		}
+	}
+}
+
+func TestAdvanceFollowsTheVariations(t *testing.T) {
+	tests := []struct {
+		name  string
+		moves []string
+		line  string
+	}{
+		{name: "main line", moves: []string{"e4", "e5", "Nf3", "Nc6"}, line: "e2e4 e7e5 g1f3 b8c6"},
+		{name: "variation", moves: []string{"e4", "c5", "Nf3"}, line: "e2e4 c7c5 g1f3"},
+	}
+
+	for _, test := range tests {
+		session := newTestSession(t, chess.Black, map[string]bool{})
+		for _, san := range test.moves {
+			if session.Finished() {
+				t.Fatalf("%s: finished before %s", test.name, san)
+			}
+			play(t, session, san)
+		}
+		if !session.Finished() || session.Line() != test.line {
+			t.Errorf("%s: got the line %q, finished: %v, expected %q, finished", test.name,
+				session.Line(), session.Finished(), test.line)
+		}
+		if session.ExpectedMove() != nil {
+			t.Errorf("%s: got the expected move %s at the end of the line", test.name, session.ExpectedMove())
+		}
+	}
+
+	session := newTestSession(t, chess.Black, map[string]bool{})
+	if session.Advance(chess.StartingPosition().String()) {
+		t.Error("a position out of the tree should not be followed")
+	}
+	if session.LinesCount() != 2 {
+		t.Errorf("got %d lines, expected 2", session.LinesCount())
+	}
+}
+
+func TestResultsTellHowTheMovesWereFound(t *testing.T) {
+	tests := []struct {
+		name          string
+		wrongAttempts int
+		hints         int
+
+		status   MoveStatus
+		mistakes int
+	}{
+		{name: "first try", status: FoundFirstTry},
+		{name: "after mistakes", wrongAttempts: 2, status: FoundAfterMistakes, mistakes: 2},
+		{name: "origin cell", hints: 1, status: FoundWithHints},
+		{name: "arrow after a mistake", wrongAttempts: 1, hints: 2, status: FoundWithHints, mistakes: 1},
+		{name: "played by a hint", hints: 3, status: Failed, mistakes: 1},
+	}
+
+	for _, test := range tests {
+		session := newTestSession(t, chess.White, map[string]bool{})
+		for attempt := 0; attempt < test.wrongAttempts; attempt++ {
+			if session.CheckMove(wrongMove(t, session)) {
+				t.Fatalf("%s: a wrong move has been accepted", test.name)
+			}
+		}
+		for hint := 1; hint <= test.hints; hint++ {
+			level, move := session.NextHint()
+			if level != HintLevel(hint) || move.String() != "e2e4" {
+				t.Fatalf("%s: got the hint %d for %s, expected %d for e2e4", test.name, level, move, hint)
+			}
+		}
+		play(t, session, "e4")
+
+		if level, move := session.NextHint(); level != NoHint || move != nil {
+			t.Errorf("%s: got a hint during the turn of the computer", test.name)
+		}
+		if session.Mistakes() != test.mistakes || session.Hints() != test.hints {
+			t.Errorf("%s: got %d mistakes and %d hints, expected %d and %d", test.name,
+				session.Mistakes(), session.Hints(), test.mistakes, test.hints)
+		}
+		results := session.Results()
+		if len(results) != 1 {
+			t.Fatalf("%s: got %d results, expected 1", test.name, len(results))
+		}
+		result := results[0]
+		if result.Status != test.status || result.WrongAttempts != test.wrongAttempts || result.Hints != test.hints {
+			t.Errorf("%s: got %s with %d wrong attempts and %d hints, expected %s, %d and %d", test.name,
+				result.Status, result.WrongAttempts, result.Hints, test.status, test.wrongAttempts, test.hints)
+		}
+		if result.Ply != 1 || result.MoveNumber != 1 || !result.WhiteMove || result.San != "e4" {
+			t.Errorf("%s: got the move %d. %s at the ply %d, expected 1. e4 at the ply 1", test.name,
+				result.MoveNumber, result.San, result.Ply)
+		}
+	}
+}
+
+func TestResultsOnlyHoldTheMovesOfTheUser(t *testing.T) {
+	session := newTestSession(t, chess.Black, map[string]bool{})
+	for _, san := range []string{"e4", "e5", "Nf3", "Nc6"} {
+		play(t, session, san)
+	}
+
+	results := session.Results()
+	if len(results) != 2 || results[0].San != "e5" || results[1].San != "Nc6" {
+		t.Fatalf("got %d results, expected the ones of e5 and Nc6", len(results))
+	}
+	if results[1].Ply != 4 || results[1].MoveNumber != 2 || results[1].WhiteMove {
+		t.Errorf("got Nc6 as the move %d at the ply %d, expected the black move 2 at the ply 4",
+			results[1].MoveNumber, results[1].Ply)
+	}
+}
+
+// exportedResults are results as found in a session, with fixed durations.
+var exportedResults = []MoveResult{
+	{Ply: 1, MoveNumber: 1, WhiteMove: true, San: "e4", Fen: "fen1", Status: FoundFirstTry,
+		Duration: 1200 * time.Millisecond},
+	{Ply: 4, MoveNumber: 2, WhiteMove: false, San: "Nc6", Fen: "fen4", Status: Failed,
+		WrongAttempts: 1, Hints: 3, Duration: 20 * time.Second},
+}
+
+func TestWriteResultsCSV(t *testing.T) {
+	var output bytes.Buffer
+	err := WriteResultsCSV(&output, exportedResults)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "ply,moveNumber,side,move,status,wrongAttempts,hints,timedOut,seconds,fen\n" +
+		"1,1,white,e4,firstTry,0,0,false,1.2,fen1\n" +
+		"4,2,black,Nc6,failed,1,3,false,20.0,fen4\n"
+	if output.String() != expected {
+		t.Errorf("got:\n%s\nexpected:\n%s", output.String(), expected)
+	}
+}
+
+func TestWriteResultsJSON(t *testing.T) {
+	var output bytes.Buffer
+	err := WriteResultsJSON(&output, exportedResults)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `[
+  {
+    "ply": 1,
+    "moveNumber": 1,
+    "side": "white",
+    "move": "e4",
+    "status": "firstTry",
+    "wrongAttempts": 0,
+    "hints": 0,
+    "timedOut": false,
+    "seconds": 1.2,
+    "fen": "fen1"
+  },
+  {
+    "ply": 4,
+    "moveNumber": 2,
+    "side": "black",
+    "move": "Nc6",
+    "status": "failed",
+    "wrongAttempts": 1,
+    "hints": 3,
+    "timedOut": false,
+    "seconds": 20,
+    "fen": "fen4"
+  }
+]
+`
+	if output.String() != expected {
+		t.Errorf("got:\n%s\nexpected:\n%s", output.String(), expected)
+	}
+}
+
+func TestSessionStartsAfterTheSkippedMoves(t *testing.T) {
+	session := NewSession(loadGame(t, testGame), chess.Black, map[string]bool{}, 2)
+	if len(session.SkippedMoves()) != 2 || len(session.Path()) != 0 {
+		t.Fatalf("got %d skipped moves and %d played moves, expected 2 and 0",
+			len(session.SkippedMoves()), len(session.Path()))
+	}
+	if session.CurrentPosition() != session.SkippedMoves()[1].Position.String() {
+		t.Errorf("the session should start after e5, got %s", session.CurrentPosition())
+	}
+
+	play(t, session, "Nf3")
+	play(t, session, "Nc6")
+	if len(session.Path()) != 2 || session.Path()[0].San != "Nf3" {
+		t.Errorf("got %d played moves, expected Nf3 and Nc6", len(session.Path()))
+	}
+	if session.Line() != "e2e4 e7e5 g1f3 b8c6" {
+		t.Errorf("got the line %q, expected it from the start of the game", session.Line())
+	}
+	results := session.Results()
+	if len(results) != 1 || results[0].San != "Nc6" || results[0].Ply != 4 {
+		t.Errorf("got %d results, expected the one of Nc6 at the ply 4", len(results))
+	}
+}
+
+func TestPeeksCountAsHints(t *testing.T) {
+	session := newTestSession(t, chess.White, map[string]bool{})
+	if !session.Peek() || !session.Peek() {
+		t.Fatal("the user should be able to peek during their turn")
+	}
+	play(t, session, "e4")
+	if session.Peek() {
+		t.Error("a peek during the turn of the computer should not count")
+	}
+
+	result := session.Results()[0]
+	if session.Hints() != 2 || session.Mistakes() != 0 || result.Hints != 2 || result.Status != FoundWithHints {
+		t.Errorf("got %d hints, %d mistakes and the result %s with %d hints, expected 2, 0 and %s with 2",
+			session.Hints(), session.Mistakes(), result.Status, result.Hints, FoundWithHints)
+	}
+}
+
+func TestTimeOutFailsTheMove(t *testing.T) {
+	tests := []struct {
+		name  string
+		hints int
+
+		mistakes int
+	}{
+		{name: "without hint", mistakes: 1},
+		{name: "after the arrow", hints: 2, mistakes: 1},
+		{name: "after the played move hint", hints: 3, mistakes: 1},
+	}
+
+	for _, test := range tests {
+		session := newTestSession(t, chess.White, map[string]bool{})
+		for hint := 0; hint < test.hints; hint++ {
+			session.NextHint()
+		}
+		if move := session.TimeOut(); move.String() != "e2e4" {
+			t.Fatalf("%s: got the move %s to play, expected e2e4", test.name, move)
+		}
+		// The board may report the time out twice for the same turn.
+		session.TimeOut()
+		play(t, session, "e4")
+
+		if session.TimeOut() != nil {
+			t.Errorf("%s: the turn of the computer should not time out", test.name)
+		}
+		result := session.Results()[0]
+		if session.Mistakes() != test.mistakes || result.Status != Failed || !result.TimedOut {
+			t.Errorf("%s: got %d mistakes and the result %s, timed out: %v, expected %d and %s, timed out",
+				test.name, session.Mistakes(), result.Status, result.TimedOut, test.mistakes, Failed)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package revision
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/gookit/ini/v2"
+)
+
+// ShowResults opens a window with the given outcome of the game, and the
+// results of the moves of the user, which can be exported as CSV or JSON.
+// It calls onMoveSelected with the result clicked by the user.
+func ShowResults(outcome string, results []MoveResult, onMoveSelected func(result MoveResult)) {
+	resultsWindow := fyne.CurrentApp().NewWindow(ini.String("results.windowTitle"))
+
+	resultsList := widget.NewList(
+		func() int {
+			return len(results)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(itemIndex widget.ListItemID, item fyne.CanvasObject) {
+			item.(*widget.Label).SetText(describeResult(results[itemIndex]))
+		},
+	)
+	resultsList.OnSelected = func(itemIndex widget.ListItemID) {
+		onMoveSelected(results[itemIndex])
+	}
+
+	var list fyne.CanvasObject = resultsList
+	if len(results) == 0 {
+		list = widget.NewLabel(ini.String("results.noMoves"))
+	}
+
+	exportButton := func(label string, fileName string, write func(io.Writer, []MoveResult) error) *widget.Button {
+		return widget.NewButton(label, func() {
+			saveFileDialog := dialog.NewFileSave(func(fileData fyne.URIWriteCloser, err error) {
+				if err == nil && fileData == nil {
+					return
+				}
+				if err == nil {
+					err = write(fileData, results)
+					closeErr := fileData.Close()
+					if err == nil {
+						err = closeErr
+					}
+				}
+				if err != nil {
+					fmt.Println(err)
+					dialog.ShowInformation(ini.String("results.windowTitle"), ini.String("results.exportError"), resultsWindow)
+				}
+			}, resultsWindow)
+			saveFileDialog.SetFileName(fileName)
+			saveFileDialog.Show()
+		})
+	}
+	exportButtons := container.NewHBox(
+		exportButton(ini.String("results.exportCsv"), "results.csv", WriteResultsCSV),
+		exportButton(ini.String("results.exportJson"), "results.json", WriteResultsJSON),
+	)
+
+	header := container.NewVBox(widget.NewLabel(outcome), widget.NewLabel(summarize(results)))
+	resultsWindow.SetContent(container.NewBorder(header, exportButtons, nil, nil, list))
+	resultsWindow.Resize(fyne.NewSize(450, 500))
+	resultsWindow.Show()
+}
+
+func summarize(results []MoveResult) string {
+	firstTries, wrongAttempts, hints := 0, 0, 0
+	totalDuration := time.Duration(0)
+	for _, result := range results {
+		if result.Status == FoundFirstTry {
+			firstTries++
+		}
+		wrongAttempts += result.WrongAttempts
+		hints += result.Hints
+		totalDuration += result.Duration
+	}
+
+	return fmt.Sprintf(ini.String("results.summary"), firstTries, len(results), wrongAttempts, hints,
+		totalDuration.Round(time.Second).String())
+}
+
+func describeResult(result MoveResult) string {
+	numberMarker := fmt.Sprintf("%d.", result.MoveNumber)
+	if !result.WhiteMove {
+		numberMarker = fmt.Sprintf("%d...", result.MoveNumber)
+	}
+
+	description := fmt.Sprintf("%s %s : %s (%.1f s)", numberMarker, result.San,
+		ini.String("results."+string(result.Status)), result.Duration.Seconds())
+	if result.WrongAttempts > 0 {
+		description += fmt.Sprintf(" - %s %d", ini.String("results.wrongAttempts"), result.WrongAttempts)
+	}
+	if result.Hints > 0 {
+		description += fmt.Sprintf(" - %s %d", ini.String("results.hints"), result.Hints)
+	}
+	if result.TimedOut {
+		description += " - " + ini.String("results.timedOut")
+	}
+	return description
+}